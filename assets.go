@@ -0,0 +1,26 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+// assets holds the templates and static files, so the binary runs from any directory.
+//
+//go:embed templates static
+var assets embed.FS
+
+// assetFS returns the embedded directory dir, or the directory override on disk when it is set,
+// so templates and static files can be edited without rebuilding during development.
+func assetFS(dir, override string) fs.FS {
+	if override != "" {
+		return os.DirFS(override)
+	}
+	sub, err := fs.Sub(assets, dir)
+	if err != nil {
+		// dir is one of the embedded directories, so this cannot happen.
+		panic(err)
+	}
+	return sub
+}
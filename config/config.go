@@ -0,0 +1,222 @@
+// Package config loads the server configuration from defaults, a YAML, JSON or TOML file,
+// environment variables and command-line flags, in increasing order of precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds every setting of the server.
+type Config struct {
+	Server    ServerConfig    `json:"server" yaml:"server" toml:"server"`
+	API       APIConfig       `json:"api" yaml:"api" toml:"api"`
+	Geocoding GeocodingConfig `json:"geocoding" yaml:"geocoding" toml:"geocoding"`
+	Paths     PathsConfig     `json:"paths" yaml:"paths" toml:"paths"`
+	Users     UsersConfig     `json:"users" yaml:"users" toml:"users"`
+	Webhooks  WebhooksConfig  `json:"webhooks" yaml:"webhooks" toml:"webhooks"`
+	Admin     AdminConfig     `json:"admin" yaml:"admin" toml:"admin"`
+	Log       LogConfig       `json:"log" yaml:"log" toml:"log"`
+}
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	Port            int      `json:"port" yaml:"port" toml:"port"`
+	ReadTimeout     Duration `json:"readTimeout" yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout    Duration `json:"writeTimeout" yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout     Duration `json:"idleTimeout" yaml:"idleTimeout" toml:"idleTimeout"`
+	ShutdownTimeout Duration `json:"shutdownTimeout" yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+	// PublicURL is the address clients reach the server at, e.g. "https://groupie.example.com", used for the
+	// absolute links of the feeds. When empty, it is taken from the Host and X-Forwarded-Proto request headers.
+	PublicURL string `json:"publicUrl" yaml:"publicUrl" toml:"publicUrl"`
+}
+
+// APIConfig configures the upstream Groupie Trackers API and the cache of its data.
+type APIConfig struct {
+	BaseURL  string   `json:"baseUrl" yaml:"baseUrl" toml:"baseUrl"`
+	Timeout  Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+	CacheTTL Duration `json:"cacheTtl" yaml:"cacheTtl" toml:"cacheTtl"`
+	// The circuit breaker stops calling the API for BreakerCooldown after BreakerThreshold consecutive failures.
+	BreakerThreshold int      `json:"breakerThreshold" yaml:"breakerThreshold" toml:"breakerThreshold"`
+	BreakerCooldown  Duration `json:"breakerCooldown" yaml:"breakerCooldown" toml:"breakerCooldown"`
+}
+
+// GeocodingConfig configures the Nominatim geocoding service.
+type GeocodingConfig struct {
+	URL      string   `json:"url" yaml:"url" toml:"url"`
+	Interval Duration `json:"interval" yaml:"interval" toml:"interval"`
+	Timeout  Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// PathsConfig holds the directories the templates and static files are read from during development.
+// When empty, the files embedded in the binary are served.
+type PathsConfig struct {
+	Templates string `json:"templates" yaml:"templates" toml:"templates"`
+	Static    string `json:"static" yaml:"static" toml:"static"`
+}
+
+// UsersConfig configures the user accounts.
+type UsersConfig struct {
+	Store         string `json:"store" yaml:"store" toml:"store"`
+	StorePath     string `json:"storePath" yaml:"storePath" toml:"storePath"`
+	SessionSecret string `json:"sessionSecret" yaml:"sessionSecret" toml:"sessionSecret"`
+}
+
+// WebhooksConfig configures the outgoing webhooks notified on dataset changes.
+type WebhooksConfig struct {
+	File string `json:"file" yaml:"file" toml:"file"`
+}
+
+// AdminConfig configures the administration pages under /admin/.
+type AdminConfig struct {
+	// Token is the password of the administration pages, which are disabled when it is empty.
+	Token string `json:"token" yaml:"token" toml:"token"`
+}
+
+// LogConfig configures the server logs.
+type LogConfig struct {
+	Level  string `json:"level" yaml:"level" toml:"level"`    // "debug", "info", "warn" or "error".
+	Format string `json:"format" yaml:"format" toml:"format"` // "text" or "json".
+}
+
+// Duration is a time.Duration written as a string such as "5m" or "10s" in configuration files.
+type Duration time.Duration
+
+// Std returns the duration as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String formats the duration like time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText formats the duration like time.Duration.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a duration such as "1m30s".
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Set parses a duration flag or environment variable such as "30s".
+func (d *Duration) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     Duration(5 * time.Second),
+			WriteTimeout:    Duration(10 * time.Second),
+			IdleTimeout:     Duration(15 * time.Second),
+			ShutdownTimeout: Duration(10 * time.Second),
+		},
+		API: APIConfig{
+			BaseURL:          "https://groupietrackers.herokuapp.com/api",
+			Timeout:          Duration(10 * time.Second),
+			CacheTTL:         Duration(5 * time.Minute),
+			BreakerThreshold: 5,
+			BreakerCooldown:  Duration(30 * time.Second),
+		},
+		Geocoding: GeocodingConfig{
+			URL:      "https://nominatim.openstreetmap.org/search",
+			Interval: Duration(time.Second),
+			Timeout:  Duration(10 * time.Second),
+		},
+		Users: UsersConfig{
+			Store: "file",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// UserStorePath returns the location of the user store, defaulting to a file under data/ for its kind.
+func (c Config) UserStorePath() string {
+	if c.Users.StorePath != "" {
+		return c.Users.StorePath
+	}
+	if c.Users.Store == "bolt" {
+		return "data/users.db"
+	}
+	return "data/users.json"
+}
+
+// Validate checks every setting and returns all the problems found, joined.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server.readTimeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.writeTimeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idleTimeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdownTimeout must be positive")
+	check(c.Server.PublicURL == "" || isHTTPURL(c.Server.PublicURL), "server.publicUrl must be an http(s) URL, got %q", c.Server.PublicURL)
+
+	check(isHTTPURL(c.API.BaseURL), "api.baseUrl must be an http(s) URL, got %q", c.API.BaseURL)
+	check(c.API.Timeout > 0, "api.timeout must be positive")
+	check(c.API.CacheTTL >= Duration(time.Second), "api.cacheTtl must be at least 1s")
+	check(c.API.BreakerThreshold > 0, "api.breakerThreshold must be positive, got %d", c.API.BreakerThreshold)
+	check(c.API.BreakerCooldown > 0, "api.breakerCooldown must be positive")
+
+	check(isHTTPURL(c.Geocoding.URL), "geocoding.url must be an http(s) URL, got %q", c.Geocoding.URL)
+	check(c.Geocoding.Interval >= 0, "geocoding.interval must not be negative")
+	check(c.Geocoding.Timeout > 0, "geocoding.timeout must be positive")
+
+	check(c.Paths.Templates == "" || isDir(c.Paths.Templates), "paths.templates must be an existing directory, got %q", c.Paths.Templates)
+	check(c.Paths.Static == "" || isDir(c.Paths.Static), "paths.static must be an existing directory, got %q", c.Paths.Static)
+
+	check(c.Users.Store == "file" || c.Users.Store == "bolt", "users.store must be \"file\" or \"bolt\", got %q", c.Users.Store)
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format must be \"text\" or \"json\", got %q", c.Log.Format)
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with its secrets hidden, for printing.
+func (c Config) Redacted() Config {
+	if c.Users.SessionSecret != "" {
+		c.Users.SessionSecret = "<redacted>"
+	}
+	if c.Admin.Token != "" {
+		c.Admin.Token = "<redacted>"
+	}
+	return c
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL.
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && !strings.ContainsAny(s, " \t")
+}
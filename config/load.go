@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configEnv is the environment variable naming the configuration file when the -config flag is not given.
+const configEnv = "GROUPIE_CONFIG"
+
+// option is a setting that can be overridden by environment variables and a command-line flag.
+type option struct {
+	flag  string
+	env   []string // Checked in order; the first one set wins.
+	usage string
+	value func(c *Config) flag.Value
+}
+
+// options lists every setting that can be overridden outside the configuration file.
+// The unprefixed environment variables are kept for compatibility with earlier versions.
+var options = []option{
+	{"port", []string{"GROUPIE_PORT", "PORT"}, "port the server listens on",
+		func(c *Config) flag.Value { return (*intValue)(&c.Server.Port) }},
+	{"read-timeout", []string{"GROUPIE_READ_TIMEOUT"}, "maximum duration for reading a request",
+		func(c *Config) flag.Value { return &c.Server.ReadTimeout }},
+	{"write-timeout", []string{"GROUPIE_WRITE_TIMEOUT"}, "maximum duration for writing a response",
+		func(c *Config) flag.Value { return &c.Server.WriteTimeout }},
+	{"idle-timeout", []string{"GROUPIE_IDLE_TIMEOUT"}, "maximum duration of an idle keep-alive connection",
+		func(c *Config) flag.Value { return &c.Server.IdleTimeout }},
+	{"shutdown-timeout", []string{"GROUPIE_SHUTDOWN_TIMEOUT"}, "maximum duration of a graceful shutdown",
+		func(c *Config) flag.Value { return &c.Server.ShutdownTimeout }},
+	{"public-url", []string{"GROUPIE_PUBLIC_URL"}, "address clients reach the server at, for the links of the feeds",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Server.PublicURL) }},
+	{"api-url", []string{"GROUPIE_API_URL"}, "base URL of the Groupie Trackers API",
+		func(c *Config) flag.Value { return (*stringValue)(&c.API.BaseURL) }},
+	{"api-timeout", []string{"GROUPIE_API_TIMEOUT"}, "timeout of a request to the API",
+		func(c *Config) flag.Value { return &c.API.Timeout }},
+	{"cache-ttl", []string{"GROUPIE_CACHE_TTL"}, "how long the API data is cached before it is refreshed",
+		func(c *Config) flag.Value { return &c.API.CacheTTL }},
+	{"breaker-threshold", []string{"GROUPIE_BREAKER_THRESHOLD"}, "consecutive API failures opening the circuit breaker",
+		func(c *Config) flag.Value { return (*intValue)(&c.API.BreakerThreshold) }},
+	{"breaker-cooldown", []string{"GROUPIE_BREAKER_COOLDOWN"}, "how long the circuit breaker stays open before the API is tried again",
+		func(c *Config) flag.Value { return &c.API.BreakerCooldown }},
+	{"geocoding-url", []string{"GROUPIE_GEOCODING_URL"}, "Nominatim search endpoint",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Geocoding.URL) }},
+	{"geocoding-interval", []string{"GROUPIE_GEOCODING_INTERVAL"}, "minimum delay between two geocoding requests",
+		func(c *Config) flag.Value { return &c.Geocoding.Interval }},
+	{"geocoding-timeout", []string{"GROUPIE_GEOCODING_TIMEOUT"}, "timeout of a geocoding request",
+		func(c *Config) flag.Value { return &c.Geocoding.Timeout }},
+	{"templates", []string{"GROUPIE_TEMPLATES"}, "read the HTML templates from this directory instead of the binary",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Paths.Templates) }},
+	{"static", []string{"GROUPIE_STATIC"}, "read the static files from this directory instead of the binary",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Paths.Static) }},
+	{"user-store", []string{"GROUPIE_USER_STORE", "USER_STORE"}, `user store kind, "file" or "bolt"`,
+		func(c *Config) flag.Value { return (*stringValue)(&c.Users.Store) }},
+	{"user-store-path", []string{"GROUPIE_USER_STORE_PATH", "USER_STORE_PATH"}, "location of the user store (default data/users.json or data/users.db)",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Users.StorePath) }},
+	{"session-secret", []string{"GROUPIE_SESSION_SECRET", "SESSION_SECRET"}, "key signing the session cookies",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Users.SessionSecret) }},
+	{"webhooks-file", []string{"GROUPIE_WEBHOOKS_FILE", "WEBHOOKS_FILE"}, "JSON file listing the outgoing webhooks",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Webhooks.File) }},
+	{"admin-token", []string{"GROUPIE_ADMIN_TOKEN"}, "password of the /admin/ pages, disabled when empty",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Admin.Token) }},
+	{"log-level", []string{"GROUPIE_LOG_LEVEL"}, "minimum level of the logs: debug, info, warn or error",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Log.Level) }},
+	{"log-format", []string{"GROUPIE_LOG_FORMAT"}, `format of the logs, "text" or "json"`,
+		func(c *Config) flag.Value { return (*stringValue)(&c.Log.Format) }},
+}
+
+// stringValue is a string setting as a flag.Value.
+type stringValue string
+
+func (s *stringValue) String() string     { return string(*s) }
+func (s *stringValue) Set(v string) error { *s = stringValue(v); return nil }
+
+// intValue is an integer setting as a flag.Value.
+type intValue int
+
+func (i *intValue) String() string { return strconv.Itoa(int(*i)) }
+func (i *intValue) Set(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*i = intValue(n)
+	return nil
+}
+
+// loader parses the command-line flags and merges them with the other sources.
+type loader struct {
+	fs   *flag.FlagSet
+	path *string
+	// flags holds the values of the flags; only the ones explicitly set are applied.
+	flags Config
+}
+
+// newLoader defines the -config flag and one flag per option on a new flag set.
+func newLoader(name string) *loader {
+	l := &loader{fs: flag.NewFlagSet(name, flag.ContinueOnError), flags: Default()}
+	l.path = l.fs.String("config", "", "configuration file (.yaml, .yml, .json or .toml), also read from "+configEnv)
+	for _, opt := range options {
+		usage := opt.usage
+		if len(opt.env) > 0 {
+			usage += " (env " + strings.Join(opt.env, ", ") + ")"
+		}
+		l.fs.Var(opt.value(&l.flags), opt.flag, usage)
+	}
+	return l
+}
+
+// Load returns the effective configuration: the defaults, overridden by the configuration file,
+// then by the environment variables, then by the command-line flags in args.
+// The file is given by the -config flag or the GROUPIE_CONFIG environment variable; without either, none is read.
+// When the configuration is loaded but invalid, it is returned along with the validation error.
+func Load(name string, args []string) (Config, error) {
+	return newLoader(name).load(args)
+}
+
+// load parses args and merges every source in order of precedence.
+func (l *loader) load(args []string) (Config, error) {
+	if err := l.fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if l.fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", l.fs.Arg(0))
+	}
+
+	cfg := Default()
+
+	path := *l.path
+	if path == "" {
+		path = os.Getenv(configEnv)
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, opt := range options {
+		for _, name := range opt.env {
+			if value, ok := os.LookupEnv(name); ok && value != "" {
+				if err := opt.value(&cfg).Set(value); err != nil {
+					return Config{}, fmt.Errorf("environment variable %s: %w", name, err)
+				}
+				break
+			}
+		}
+	}
+
+	var err error
+	l.fs.Visit(func(f *flag.Flag) {
+		for _, opt := range options {
+			if opt.flag == f.Name && err == nil {
+				err = opt.value(&cfg).Set(f.Value.String())
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// loadFile decodes a configuration file over cfg, choosing the format from the file extension.
+// Unknown keys are rejected so that typos do not go unnoticed.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parse %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml, .json or .toml", path)
+	}
+	return nil
+}
+
+// Command runs the "config" subcommand with the given arguments, writing its output to w.
+// The only subcommand is "print", which writes the effective configuration with its secrets redacted;
+// it accepts the same flags as the server plus -format (yaml, json or toml).
+func Command(args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: config print [-format yaml|json|toml] [flags]")
+	}
+
+	l := newLoader("config print")
+	format := l.fs.String("format", "yaml", "output format: yaml, json or toml")
+	cfg, err := l.load(args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil && cfg == (Config{}) {
+		return err
+	}
+	if printErr := Print(w, cfg.Redacted(), *format); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
+// Print writes the configuration in the given format: yaml, json or toml.
+func Print(w io.Writer, cfg Config, format string) error {
+	switch format {
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cfg)
+	case "toml":
+		return toml.NewEncoder(w).Encode(cfg)
+	default:
+		return fmt.Errorf("unsupported format %q, use yaml, json or toml", format)
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes a configuration file in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", "server:\n  port: 7000\napi:\n  cacheTtl: 1m\n  timeout: 3s\n")
+	t.Setenv("GROUPIE_CONFIG", path)
+	t.Setenv("GROUPIE_CACHE_TTL", "2m")
+	t.Setenv("PORT", "7100")
+
+	cfg, err := Load("test", []string{"-port", "7200"})
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if cfg.Server.Port != 7200 {
+		t.Errorf("port = %d, want the flag 7200", cfg.Server.Port)
+	}
+	if cfg.API.CacheTTL.Std() != 2*time.Minute {
+		t.Errorf("cacheTtl = %v, want the environment 2m", cfg.API.CacheTTL)
+	}
+	if cfg.API.Timeout.Std() != 3*time.Second {
+		t.Errorf("timeout = %v, want the file 3s", cfg.API.Timeout)
+	}
+	if cfg.Server.ReadTimeout.Std() != 5*time.Second {
+		t.Errorf("readTimeout = %v, want the default 5s", cfg.Server.ReadTimeout)
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"config.yaml", "log:\n  level: debug\n"},
+		{"config.yml", "log:\n  level: debug\n"},
+		{"config.json", `{"log": {"level": "debug"}}`},
+		{"config.toml", "[log]\nlevel = \"debug\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load("test", []string{"-config", writeFile(t, tt.name, tt.content)})
+			if err != nil {
+				t.Fatalf("Load() = %v", err)
+			}
+			if cfg.Log.Level != "debug" {
+				t.Errorf("log.level = %q, want debug", cfg.Log.Level)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // Name of the configuration file, if any.
+		content string
+		args    []string
+		want    string
+	}{
+		{name: "unknown yaml key", file: "config.yaml", content: "server:\n  prot: 80\n", want: "prot"},
+		{name: "unknown json key", file: "config.json", content: `{"server": {"prot": 80}}`, want: "prot"},
+		{name: "unknown toml key", file: "config.toml", content: "[server]\nprot = 80\n", want: "server.prot"},
+		{name: "unsupported format", file: "config.ini", content: "port=80", want: "unsupported format"},
+		{name: "invalid duration", file: "config.yaml", content: "api:\n  timeout: soon\n", want: "soon"},
+		{name: "invalid flag", args: []string{"-port", "eighty"}, want: "invalid integer"},
+		{name: "extra argument", args: []string{"serve"}, want: `unexpected argument "serve"`},
+		{name: "port out of range", args: []string{"-port", "70000"}, want: "server.port must be between 1 and 65535"},
+		{name: "invalid url", args: []string{"-api-url", "ftp://example.com"}, want: "api.baseUrl"},
+		{name: "invalid public url", args: []string{"-public-url", "groupie.example.com"}, want: "server.publicUrl"},
+		{name: "unknown store", args: []string{"-user-store", "sql"}, want: "users.store"},
+		{name: "unknown log level", args: []string{"-log-level", "loud"}, want: "log.level"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file, tt.content)}, args...)
+			}
+			_, err := Load("test", args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Users.SessionSecret = "session"
+	cfg.Admin.Token = "admin"
+	redacted := cfg.Redacted()
+	if redacted.Users.SessionSecret != "<redacted>" || redacted.Admin.Token != "<redacted>" {
+		t.Errorf("Redacted() = %+v, %+v", redacted.Users, redacted.Admin)
+	}
+	if cfg.Users.SessionSecret != "session" {
+		t.Error("Redacted() modified the configuration")
+	}
+}
@@ -1,3 +1,12 @@
 module groopie_local
 
 go 1.22.2
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/crypto v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.28.0 // indirect
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// sessionCookie is the name of the cookie holding the signed session token.
+const sessionCookie = "groupie_session"
+
+// csrfField is the name of the form field holding the CSRF token of the session.
+const csrfField = "csrf"
+
+// currentUser returns the logged-in user, or nil for anonymous visitors and invalid or ended sessions.
+func currentUser(r *http.Request) *models.User {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	user, err := services.ParseSessionToken(cookie.Value)
+	if err != nil {
+		return nil
+	}
+	return &user
+}
+
+// csrfToken returns the CSRF token the forms of the page must post back, or "" for anonymous visitors.
+func csrfToken(r *http.Request) string {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+	return services.CSRFToken(cookie.Value)
+}
+
+// checkCSRF fails with 403 Forbidden unless the posted form carries the CSRF token of the session,
+// so other sites cannot make a logged-in user's browser change their account.
+func checkCSRF(r *http.Request) error {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || !services.ValidCSRFToken(cookie.Value, r.PostFormValue(csrfField)) {
+		return forbidden("The form has expired. Please reload the page and try again.")
+	}
+	return nil
+}
+
+// setSessionCookie logs the user in by storing a signed session token in a cookie.
+// The cookie is HttpOnly, SameSite=Lax and Secure when the request came over HTTPS.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, user models.User) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    services.NewSessionToken(user),
+		Path:     "/",
+		MaxAge:   int(services.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie logs the user out by expiring the session cookie.
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// safeRedirect returns next if it is a local path, or fallback otherwise, to avoid open redirects.
+func safeRedirect(next, fallback string) string {
+	if strings.HasPrefix(next, "/") && !strings.HasPrefix(next, "//") && !strings.HasPrefix(next, "/\\") {
+		return next
+	}
+	return fallback
+}
+
+// RegisterHandler shows the registration form and creates the account on submission.
+// An invalid submission shows the form again with the problem, with a 400 Bad Request status,
+// or 409 Conflict when the username is taken.
+func RegisterHandler(w http.ResponseWriter, r *http.Request) error {
+	data := TemplateData{Title: "Register - Groupie Tracker", Form: "register", Next: safeRedirect(r.FormValue("next"), "/my-artists")}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		renderTemplate(w, r, "account", data)
+		return nil
+	case http.MethodPost:
+	default:
+		return methodNotAllowed()
+	}
+
+	username := strings.TrimSpace(r.FormValue("username"))
+	user, err := services.Register(username, r.FormValue("password"))
+	switch {
+	case errors.Is(err, services.ErrUserExists):
+		data.Message = "This username is already taken."
+		renderPage(w, r, http.StatusConflict, "account", data)
+		return nil
+	case errors.Is(err, services.ErrInvalidUsername), errors.Is(err, services.ErrWeakPassword), errors.Is(err, services.ErrPasswordTooLong):
+		data.Message = err.Error()
+		renderPage(w, r, http.StatusBadRequest, "account", data)
+		return nil
+	case errors.Is(err, services.ErrAccountsDisabled):
+		data.Message = err.Error()
+		renderPage(w, r, http.StatusNotFound, "account", data)
+		return nil
+	case err != nil:
+		return internalError("Unable to create the account. Please try again later.", err)
+	}
+
+	setSessionCookie(w, r, user)
+	http.Redirect(w, r, data.Next, http.StatusSeeOther)
+	return nil
+}
+
+// LoginHandler shows the login form and opens a session on valid credentials.
+// Invalid credentials show the form again with a 401 Unauthorized status.
+func LoginHandler(w http.ResponseWriter, r *http.Request) error {
+	data := TemplateData{Title: "Login - Groupie Tracker", Form: "login", Next: safeRedirect(r.FormValue("next"), "/my-artists")}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		renderTemplate(w, r, "account", data)
+		return nil
+	case http.MethodPost:
+	default:
+		return methodNotAllowed()
+	}
+
+	username := strings.TrimSpace(r.FormValue("username"))
+	user, err := services.Authenticate(username, r.FormValue("password"))
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		data.Message = "Invalid username or password."
+		renderPage(w, r, http.StatusUnauthorized, "account", data)
+		return nil
+	case errors.Is(err, services.ErrAccountsDisabled):
+		data.Message = err.Error()
+		renderPage(w, r, http.StatusNotFound, "account", data)
+		return nil
+	case err != nil:
+		return internalError("Unable to log in. Please try again later.", err)
+	}
+
+	setSessionCookie(w, r, user)
+	http.Redirect(w, r, data.Next, http.StatusSeeOther)
+	return nil
+}
+
+// LogoutHandler closes the session, ending every session of the user so a copied token stops working too.
+// Only POST is accepted so links can't log users out.
+func LogoutHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return methodNotAllowed()
+	}
+	if user := currentUser(r); user != nil {
+		if err := checkCSRF(r); err != nil {
+			return err
+		}
+		if err := services.EndSessions(user.Username); err != nil {
+			return internalError("Unable to log out. Please try again later.", err)
+		}
+	}
+	clearSessionCookie(w)
+	http.Redirect(w, r, "/home", http.StatusSeeOther)
+	return nil
+}
+
+// FavoriteHandler adds or removes an artist from the user's favorites (POST /favorites/{id}).
+// The form field "action" is "add" or "remove"; the user is sent back to "next".
+func FavoriteHandler(w http.ResponseWriter, r *http.Request) error {
+	return updateArtistList(w, r, "/favorites/", services.SetFavorite)
+}
+
+// FollowHandler follows or unfollows an artist (POST /following/{id}), like FavoriteHandler.
+func FollowHandler(w http.ResponseWriter, r *http.Request) error {
+	return updateArtistList(w, r, "/following/", services.SetFollowing)
+}
+
+// updateArtistList applies a favorite or follow change for the logged-in user, failing with 404 Not Found
+// for artists that do not exist.
+func updateArtistList(w http.ResponseWriter, r *http.Request, prefix string, set func(string, int, bool) (models.User, error)) error {
+	if r.Method != http.MethodPost {
+		return methodNotAllowed()
+	}
+
+	next := safeRedirect(r.FormValue("next"), "/home")
+	user := currentUser(r)
+	if user == nil {
+		http.Redirect(w, r, "/login?next="+url.QueryEscape(next), http.StatusSeeOther)
+		return nil
+	}
+	if err := checkCSRF(r); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, prefix))
+	if err != nil || id <= 0 {
+		return badRequest("Invalid artist ID")
+	}
+	_, found, err := services.GetArtist(r.Context(), id)
+	if err != nil {
+		return unavailable(err)
+	}
+	if !found {
+		return notFound("Artist not found")
+	}
+
+	if _, err := set(user.Username, id, r.FormValue("action") != "remove"); err != nil {
+		return internalError("Unable to update your artists. Please try again later.", err)
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+	return nil
+}
+
+// MyArtistsHandler lists the artists the user favorited or follows, with the same search and filters as the home page.
+func MyArtistsHandler(w http.ResponseWriter, r *http.Request) error {
+	user := currentUser(r)
+	if user == nil {
+		http.Redirect(w, r, "/login?next=/my-artists", http.StatusSeeOther)
+		return nil
+	}
+
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	var mine []models.ArtistFull
+	for _, artist := range artists {
+		if user.IsFavorite(artist.Artist.ID) || user.IsFollowing(artist.Artist.ID) {
+			mine = append(mine, artist)
+		}
+	}
+
+	filters := ParseFilters(r)
+	data := TemplateData{
+		Title:       "My Artists - Groupie Tracker",
+		Artists:     FilterArtists(mine, filters),
+		SearchQuery: filters.SearchQuery,
+		SearchType:  filters.SearchType,
+		User:        user,
+		Next:        r.URL.RequestURI(),
+		BasePath:    "/my-artists",
+		Query:       r.URL.RawQuery,
+	}
+	if len(mine) == 0 {
+		data.Message = "You have no favorite or followed artists yet. Use the stars on the artist cards to add some."
+	}
+
+	renderTemplate(w, r, "home", data)
+	return nil
+}
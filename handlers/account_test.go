@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"groopie_local/services"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccountFormStatuses(t *testing.T) {
+	if err := LoadTemplates(os.DirFS("../templates"), false); err != nil {
+		t.Fatal(err)
+	}
+	store, err := services.NewFileUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	services.ConfigureUserStore(store)
+	defer services.ConfigureUserStore(nil)
+	if _, err := services.Register("alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+		method  string
+		form    url.Values
+		status  int
+	}{
+		{"register form", RegisterHandler, http.MethodGet, nil, http.StatusOK},
+		{"register weak password", RegisterHandler, http.MethodPost, url.Values{"username": {"bob"}, "password": {"short"}}, http.StatusBadRequest},
+		{"register password too long", RegisterHandler, http.MethodPost, url.Values{"username": {"bob"}, "password": {strings.Repeat("x", 73)}}, http.StatusBadRequest},
+		{"register invalid username", RegisterHandler, http.MethodPost, url.Values{"username": {"b!"}, "password": {"long enough"}}, http.StatusBadRequest},
+		{"register taken username", RegisterHandler, http.MethodPost, url.Values{"username": {"alice"}, "password": {"long enough"}}, http.StatusConflict},
+		{"register", RegisterHandler, http.MethodPost, url.Values{"username": {"bob"}, "password": {"long enough"}}, http.StatusSeeOther},
+		{"register wrong method", RegisterHandler, http.MethodPut, nil, http.StatusMethodNotAllowed},
+		{"login form", LoginHandler, http.MethodGet, nil, http.StatusOK},
+		{"login wrong password", LoginHandler, http.MethodPost, url.Values{"username": {"alice"}, "password": {"wrong password"}}, http.StatusUnauthorized},
+		{"login unknown user", LoginHandler, http.MethodPost, url.Values{"username": {"carol"}, "password": {"whatever"}}, http.StatusUnauthorized},
+		{"login", LoginHandler, http.MethodPost, url.Values{"username": {"alice"}, "password": {"correct horse"}}, http.StatusSeeOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/account", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAccountActions(t *testing.T) {
+	if err := LoadTemplates(os.DirFS("../templates"), false); err != nil {
+		t.Fatal(err)
+	}
+	loadTestData(t)
+	store, err := services.NewFileUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	services.ConfigureUserStore(store)
+	defer services.ConfigureUserStore(nil)
+	user, err := services.Register("alice", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := services.NewSessionToken(user)
+	csrf := services.CSRFToken(session)
+
+	// post sends a form as alice.
+	post := func(handler HandlerFunc, path string, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Pages rendered for alice carry the token in their forms.
+	r := httptest.NewRequest(http.MethodGet, "/home", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+	w := httptest.NewRecorder()
+	HandlerFunc(HomeHandler).ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), `name="csrf" value="`+csrf+`"`) {
+		t.Error("the home page forms do not carry the CSRF token")
+	}
+
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+		path    string
+		form    url.Values
+		status  int
+	}{
+		{"favorite without token", FavoriteHandler, "/favorites/1", url.Values{}, http.StatusForbidden},
+		{"favorite with another token", FavoriteHandler, "/favorites/1", url.Values{"csrf": {services.CSRFToken("other")}}, http.StatusForbidden},
+		{"favorite unknown artist", FavoriteHandler, "/favorites/999", url.Values{"csrf": {csrf}}, http.StatusNotFound},
+		{"favorite invalid artist", FavoriteHandler, "/favorites/abc", url.Values{"csrf": {csrf}}, http.StatusBadRequest},
+		{"favorite", FavoriteHandler, "/favorites/1", url.Values{"csrf": {csrf}}, http.StatusSeeOther},
+		{"follow without token", FollowHandler, "/following/1", url.Values{}, http.StatusForbidden},
+		{"follow", FollowHandler, "/following/1", url.Values{"csrf": {csrf}}, http.StatusSeeOther},
+		{"save search without token", SavedSearchesHandler, "/searches", url.Values{"name": {"All"}}, http.StatusForbidden},
+		{"save search", SavedSearchesHandler, "/searches", url.Values{"name": {"All"}, "csrf": {csrf}}, http.StatusSeeOther},
+		{"delete search without token", SavedSearchHandler, "/searches/abc/delete", url.Values{}, http.StatusForbidden},
+		{"clear notifications without token", ClearNotificationsHandler, "/notifications/clear", url.Values{}, http.StatusForbidden},
+		{"clear notifications", ClearNotificationsHandler, "/notifications/clear", url.Values{"csrf": {csrf}}, http.StatusSeeOther},
+		{"logout without token", LogoutHandler, "/logout", url.Values{}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := post(tt.handler, tt.path, tt.form).Code; status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+
+	user, err = services.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsFavorite(1) || !user.IsFollowing(1) || user.IsFavorite(999) || len(user.SavedSearches) != 1 {
+		t.Errorf("alice = %+v, want artist 1 favorited and followed and one saved search", user)
+	}
+
+	// Logging out ends the session, so the token no longer logs anyone in.
+	if status := post(LogoutHandler, "/logout", url.Values{"csrf": {csrf}}).Code; status != http.StatusSeeOther {
+		t.Fatalf("logout status = %d, want 303", status)
+	}
+	if location := post(FavoriteHandler, "/favorites/1", url.Values{"csrf": {csrf}}).Header().Get("Location"); !strings.HasPrefix(location, "/login") {
+		t.Errorf("favorite after logout redirected to %q, want the login page", location)
+	}
+	r = httptest.NewRequest(http.MethodGet, "/home", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+	if currentUser(r) != nil {
+		t.Error("the session token still logs in after logging out")
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"groopie_local/services"
+	"net/http"
+)
+
+// adminToken is the password of the administration pages, which are disabled while it is empty.
+var adminToken string
+
+// SetAdminToken sets the password of the administration pages. It must be called before the server starts.
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// RequireAdmin restricts a handler to administrators, who give the admin token as the password of HTTP Basic
+// authentication, with any user name. Without a token configured, the administration pages do not exist.
+func RequireAdmin(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if adminToken == "" {
+			return notFound("")
+		}
+		_, password, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Groupie Tracker admin", charset="UTF-8"`)
+			return unauthorized("The administration pages require the admin token.")
+		}
+		return next(w, r)
+	}
+}
+
+// ChangesHandler serves the history of dataset changes detected between cache refreshes.
+// /admin/changes renders an HTML page and /admin/changes.json returns the same history as JSON.
+func ChangesHandler(w http.ResponseWriter, r *http.Request) error {
+	history := services.DiffHistory()
+
+	switch r.URL.Path {
+	case "/admin/changes":
+		renderTemplate(w, r, "changes", TemplateData{
+			Title: "Dataset Changes - Groupie Tracker",
+			Diffs: history,
+		})
+	case "/admin/changes.json":
+		writeJSON(w, r, history)
+	default:
+		return notFound("")
+	}
+	return nil
+}
+
+// WebhooksHandler serves the configured webhooks and their delivery log.
+// /admin/webhooks renders an HTML page and /admin/webhooks.json returns the delivery log as JSON.
+func WebhooksHandler(w http.ResponseWriter, r *http.Request) error {
+	data := TemplateData{Title: "Webhooks - Groupie Tracker"}
+	if dispatcher := services.ConfiguredWebhooks(); dispatcher != nil {
+		data.Webhooks = dispatcher.Webhooks()
+		data.Deliveries = dispatcher.Deliveries()
+	}
+
+	switch r.URL.Path {
+	case "/admin/webhooks":
+		renderTemplate(w, r, "webhooks", data)
+	case "/admin/webhooks.json":
+		// Secrets are deliberately left out of the JSON response, and the URLs are redacted.
+		writeJSON(w, r, data.Deliveries)
+	default:
+		return notFound("")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	defer SetAdminToken("")
+	handler := RequireAdmin(func(w http.ResponseWriter, r *http.Request) error {
+		writeJSON(w, r, "ok")
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		token    string
+		password string // Sent with Basic authentication when not empty.
+		status   int
+	}{
+		{name: "disabled", token: "", password: "", status: http.StatusNotFound},
+		{name: "disabled with password", token: "", password: "anything", status: http.StatusNotFound},
+		{name: "no credentials", token: "s3cret", status: http.StatusUnauthorized},
+		{name: "wrong password", token: "s3cret", password: "guess", status: http.StatusUnauthorized},
+		{name: "admin", token: "s3cret", password: "s3cret", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAdminToken(tt.token)
+			r := httptest.NewRequest(http.MethodGet, "/admin/changes.json", nil)
+			if tt.password != "" {
+				r.SetBasicAuth("admin", tt.password)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); (challenge != "") != (tt.status == http.StatusUnauthorized) {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, w.Code)
+			}
+		})
+	}
+}
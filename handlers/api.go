@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// similarLimit is the number of similar artists shown on the artist page and returned by default by the API.
+const similarLimit = 6
+
+// APIArtist is the JSON representation of an artist returned by the API, with its similar artists.
+type APIArtist struct {
+	models.ArtistFull
+	Similar []services.SimilarArtist `json:"similar"`
+}
+
+// APIArtistsHandler serves the artists as JSON:
+// /api/v1/artists lists every artist, /api/v1/artists/{id} returns one artist with its similar artists
+// and /api/v1/artists/{id}/similar returns only the similar artists.
+// The number of similar artists can be changed with the "limit" query parameter (0 for all of them).
+func APIArtistsHandler(w http.ResponseWriter, r *http.Request) error {
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/artists"), "/")
+	if path == "" {
+		if notModified(w, r) {
+			return nil
+		}
+		writeJSON(w, r, artists)
+		return nil
+	}
+
+	idStr, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return badRequest("Invalid artist ID")
+	}
+	limit := parseInt(r.URL.Query().Get("limit"), similarLimit)
+
+	artist, found, err := services.GetArtist(r.Context(), id)
+	if err != nil {
+		return unavailable(err)
+	}
+	if !found {
+		return notFound("Artist not found")
+	}
+	if sub != "" && sub != "similar" {
+		return notFound("")
+	}
+	if notModified(w, r) {
+		return nil
+	}
+
+	similar := services.SimilarArtists(artists, id, limit)
+	if similar == nil {
+		similar = []services.SimilarArtist{}
+	}
+	if sub == "similar" {
+		writeJSON(w, r, similar)
+		return nil
+	}
+	writeJSON(w, r, APIArtist{ArtistFull: artist, Similar: similar})
+	return nil
+}
+
+// APIStatsHandler returns the statistics of the whole dataset as JSON (/api/v1/stats).
+func APIStatsHandler(w http.ResponseWriter, r *http.Request) error {
+	stats, err := services.GetStats(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+	if notModified(w, r) {
+		return nil
+	}
+	writeJSON(w, r, stats)
+	return nil
+}
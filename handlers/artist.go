@@ -3,7 +3,6 @@ package handlers
 import (
 	"groopie_local/models"
 	"groopie_local/services"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,56 +10,79 @@ import (
 
 // ArtistHandler handles HTTP requests for a specific artist.
 // It extracts the artist ID from the URL, fetches the corresponding artist data from the cache,
-// and renders the artist page or an error page if the artist is not found.
-func ArtistHandler(w http.ResponseWriter, r *http.Request) {
+// and renders the artist page. It fails with 400 Bad Request for an invalid ID and 404 Not Found for an unknown artist.
+func ArtistHandler(w http.ResponseWriter, r *http.Request) error {
 	// Remove the "/artist/" prefix to extract the artist ID as a string.
 	idStr := strings.TrimPrefix(r.URL.Path, "/artist/")
+	// Split off an optional export suffix, e.g. "/artist/1/tour.geojson", "/artist/1/tour.json" or "/artist/1/feed.atom".
+	idStr, export, _ := strings.Cut(idStr, "/")
 
-	// If the extracted string is empty or just "/", log the issue and serve an error page.
+	// If the extracted string is empty or just "/", there is no artist to show.
 	if idStr == "" || idStr == "/" {
-		log.Println("No artist ID provided")
-		http.ServeFile(w, r, "templates/error.html") // Serve a generic error page.
-		return
+		return notFound("")
 	}
 
 	// Convert the artist ID from string to integer.
 	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 || id > 52 {
-		log.Printf("Invalid artist ID: %s", idStr)
-		http.ServeFile(w, r, "templates/error.html") // Serve error page for invalid ID.
-		return
+	if err != nil || id <= 0 {
+		return badRequest("Invalid artist ID")
 	}
 
-	// Retrieve the merged and cached artist data.
-	artistsFull, err := services.GetCachedData()
+	// Find the artist corresponding to the provided ID in the cached data.
+	artistFull, found, err := services.GetArtist(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching cached data: %v", err)
-		// Render an error template using a helper function with a custom error title.
-		renderTemplate(w, "error", TemplateData{Title: "Error"})
-		return
+		return unavailable(err)
+	}
+	if !found {
+		return notFound("Artist not found")
 	}
 
-	// Build a map to quickly look up an artist by its ID.
-	artistMap := make(map[int]models.ArtistFull)
-	for _, artist := range artistsFull {
-		artistMap[artist.Artist.ID] = artist
+	// Serve the tour exports and feeds instead of the page when requested.
+	switch export {
+	case "":
+	case "tour.geojson":
+		writeTourGeoJSON(w, r, []models.ArtistFull{artistFull})
+		return nil
+	case "tour.kml":
+		writeTourKML(w, r, artistFull.Artist.Name+" tour", []models.ArtistFull{artistFull})
+		return nil
+	case "tour.json":
+		writeJSON(w, r, services.AnalyzeTour(artistFull))
+		return nil
+	case "feed.atom":
+		writeArtistFeed(w, r, id, artistFull.Artist.Name, "atom")
+		return nil
+	case "feed.rss":
+		writeArtistFeed(w, r, id, artistFull.Artist.Name, "rss")
+		return nil
+	default:
+		return notFound("")
 	}
 
-	// Find the artist corresponding to the provided ID.
-	artistFull, found := artistMap[id]
-	if !found {
-		log.Printf("Artist with ID %d not found", id)
-		// If not found, respond with a 404 Not Found.
-		http.NotFound(w, r)
-		return
+	// Only the page is revalidated: the exports depend on geocoding results that may still change.
+	if notModified(w, r) {
+		return nil
+	}
+
+	// The page also shows the artist's similar artists, events and bandmates, found among every artist.
+	artistsFull, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
 	}
 
 	// Prepare data for the template, including a dynamic title.
 	data := TemplateData{
-		Title:  artistFull.Artist.Name + " - Groopie Tracker",
-		Artist: artistFull,
+		Title:   artistFull.Artist.Name + " - Groopie Tracker",
+		Artist:  artistFull,
+		User:    currentUser(r),
+		Next:    r.URL.RequestURI(),
+		Similar: services.SimilarArtists(artistsFull, id, similarLimit),
+		Events:  services.ArtistEvents(services.FindEvents(artistsFull), id),
+		Tour:    services.TourSummary(artistFull),
+		Members: services.ArtistMembers(artistsFull, id),
 	}
 
 	// Render the artist template with the retrieved data.
-	renderTemplate(w, "artist", data)
+	renderTemplate(w, r, "artist", data)
+	return nil
 }
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestArtistExports(t *testing.T) {
+	if err := LoadTemplates(os.DirFS("../templates"), false); err != nil {
+		t.Fatal(err)
+	}
+	loadTourData(t)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string // Expected in the response.
+	}{
+		{path: "/artist/1", status: http.StatusOK, contentType: "text/html; charset=utf-8", body: "Queen"},
+		{path: "/artist/1/tour.geojson", status: http.StatusOK, contentType: "application/geo+json", body: `"name":"Queen tour"`},
+		{path: "/artist/1/tour.kml", status: http.StatusOK, contentType: "application/vnd.google-earth.kml+xml; charset=utf-8", body: "<name>Queen tour</name>"},
+		{path: "/artist/1/feed.atom", status: http.StatusOK, contentType: "application/atom+xml; charset=utf-8", body: "<title>Queen - Groupie Tracker updates</title>"},
+		{path: "/artist/1/feed.rss", status: http.StatusOK, contentType: "application/rss+xml; charset=utf-8", body: "<title>Queen - Groupie Tracker updates</title>"},
+		{path: "/artist/1/feed.json", status: http.StatusNotFound},
+		{path: "/artist/3/feed.atom", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandlerFunc(ArtistHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("the response does not contain %q", tt.body)
+			}
+			if strings.Contains(tt.contentType, "xml") && !strings.HasPrefix(w.Body.String(), xml.Header) {
+				t.Error("the XML response does not start with the XML declaration")
+			}
+		})
+	}
+}
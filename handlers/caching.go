@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"groopie_local/services"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notModified sets the validators of a page or JSON response that depends only on the cached data and the request URL:
+// an ETag derived from the cache generation, the path and the query parameters, and a Last-Modified date.
+// It reports whether the copy of the client, given by If-None-Match or If-Modified-Since, is still current,
+// in which case it responded 304 Not Modified and the handler must not write anything else.
+// Pages of logged-in users also depend on the user, so they get no validators.
+func notModified(w http.ResponseWriter, r *http.Request) bool {
+	return notModifiedVariant(w, r, "")
+}
+
+// notModifiedVariant is notModified for a response that also depends on variant, such as a date resolved from
+// the current time when the URL does not give one. A non-empty variant is part of the ETag, and the response
+// gets no Last-Modified date, since it may change without the data changing.
+func notModifiedVariant(w http.ResponseWriter, r *http.Request, variant string) bool {
+	if _, err := r.Cookie(sessionCookie); err == nil {
+		return false
+	}
+	generation, modified := services.CacheVersion()
+	if generation == 0 {
+		return false
+	}
+
+	// The pages also change with the server, whose templates may differ after a restart, and with the templates
+	// themselves when they are reloaded from disk.
+	templatesModified := templates.version()
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%d|%d|%s|%s|%s", startTime.UnixNano(), templatesModified.UnixNano(), generation, r.URL.Path, r.URL.Query().Encode(), variant)
+	etag := fmt.Sprintf(`W/"%x"`, h.Sum64())
+	for _, t := range []time.Time{startTime, templatesModified} {
+		if t.After(modified) {
+			modified = t
+		}
+	}
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	if variant == "" {
+		header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "Cookie")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || variant != "" || modified.Truncate(time.Second).After(since) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag, comparing them weakly, i.e. ignoring "W/".
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// clearValidators removes the validators set by notModified, so an error response sent instead of the page is not cached.
+func clearValidators(w http.ResponseWriter) {
+	header := w.Header()
+	header.Del("ETag")
+	header.Del("Last-Modified")
+	header.Set("Cache-Control", "no-store")
+}
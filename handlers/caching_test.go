@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"groopie_local/internal/testutil"
+	"groopie_local/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTestData fills the cache from a fake API with a single artist, and returns the API so tests can
+// change its data and refresh again.
+func loadTestData(t *testing.T) *testutil.API {
+	t.Helper()
+	api := testutil.NewAPI(t, testutil.APIResponses)
+	services.ConfigureAPI(api.URL, 5*time.Second, time.Hour)
+	if err := services.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+func TestNotModified(t *testing.T) {
+	loadTestData(t)
+
+	// The first request gets the validators to revalidate with.
+	w := httptest.NewRecorder()
+	if notModified(w, httptest.NewRequest(http.MethodGet, "/stats", nil)) {
+		t.Fatal("notModified() = true without validators in the request")
+	}
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if !strings.HasPrefix(etag, `W/"`) || lastModified == "" {
+		t.Fatalf("ETag = %q, Last-Modified = %q", etag, lastModified)
+	}
+	modified, _ := http.ParseTime(lastModified)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    bool
+	}{
+		{"matching ETag", http.MethodGet, "/stats", map[string]string{"If-None-Match": etag}, true},
+		{"strong form of the ETag", http.MethodGet, "/stats", map[string]string{"If-None-Match": strings.TrimPrefix(etag, "W/")}, true},
+		{"one of several ETags", http.MethodGet, "/stats", map[string]string{"If-None-Match": `"other", ` + etag}, true},
+		{"any ETag", http.MethodGet, "/stats", map[string]string{"If-None-Match": "*"}, true},
+		{"other ETag", http.MethodGet, "/stats", map[string]string{"If-None-Match": `W/"other"`}, false},
+		{"other query", http.MethodGet, "/stats?x=1", map[string]string{"If-None-Match": etag}, false},
+		{"ETag over date", http.MethodGet, "/stats", map[string]string{"If-None-Match": `W/"other"`, "If-Modified-Since": lastModified}, false},
+		{"not modified since", http.MethodGet, "/stats", map[string]string{"If-Modified-Since": lastModified}, true},
+		{"modified since", http.MethodGet, "/stats", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, false},
+		{"HEAD", http.MethodHead, "/stats", map[string]string{"If-None-Match": etag}, true},
+		{"POST", http.MethodPost, "/stats", map[string]string{"If-None-Match": etag}, false},
+		{"logged in", http.MethodGet, "/stats", map[string]string{"If-None-Match": etag, "Cookie": sessionCookie + "=token"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			if got := notModified(w, r); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", w.Code)
+			}
+		})
+	}
+}
+
+func TestNotModifiedVariant(t *testing.T) {
+	loadTestData(t)
+
+	etags := map[string]string{}
+	for _, variant := range []string{"", "2020-01-01", "2020-01-02"} {
+		w := httptest.NewRecorder()
+		notModifiedVariant(w, httptest.NewRequest(http.MethodGet, "/calendar", nil), variant)
+		etags[variant] = w.Header().Get("ETag")
+		if hasDate := w.Header().Get("Last-Modified") != ""; hasDate != (variant == "") {
+			t.Errorf("variant %q: Last-Modified = %q", variant, w.Header().Get("Last-Modified"))
+		}
+	}
+	if etags[""] == etags["2020-01-01"] || etags["2020-01-01"] == etags["2020-01-02"] {
+		t.Errorf("variants share ETags: %v", etags)
+	}
+
+	// A page depending on the current day is only revalidated by its ETag.
+	r := httptest.NewRequest(http.MethodGet, "/calendar", nil)
+	r.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if notModifiedVariant(httptest.NewRecorder(), r, "2020-01-01") {
+		t.Error("notModifiedVariant() = true for If-Modified-Since")
+	}
+	r = httptest.NewRequest(http.MethodGet, "/calendar", nil)
+	r.Header.Set("If-None-Match", etags["2020-01-01"])
+	if !notModifiedVariant(httptest.NewRecorder(), r, "2020-01-01") {
+		t.Error("notModifiedVariant() = false for the matching ETag")
+	}
+}
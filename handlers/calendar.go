@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// calendarDateLayout is the layout of the "date" query parameter of the calendar.
+const calendarDateLayout = "2006-01-02"
+
+// CalendarConcert is a concert shown in a calendar day.
+type CalendarConcert struct {
+	ArtistID   int
+	ArtistName string
+	Location   string
+}
+
+// CalendarDay is a single day of the calendar with its concerts.
+type CalendarDay struct {
+	Date     time.Time
+	Outside  bool // The day belongs to the previous or next month of a month view.
+	Concerts []CalendarConcert
+}
+
+// URL returns the link to the day view of the day, keeping the filters.
+func (d CalendarDay) URL(filters url.Values) string {
+	return calendarURL("day", d.Date, filters)
+}
+
+// BandMemberOption is a checkbox of the band members filter.
+type BandMemberOption struct {
+	Value   string
+	Checked bool
+}
+
+// Calendar is a month, week or day view of the concerts of the artists matching the filters.
+type Calendar struct {
+	View     string // "month", "week" or "day".
+	Date     time.Time
+	Heading  string
+	Weeks    [][]CalendarDay // Rows of seven days starting on Monday; a single day for the day view.
+	Concerts int
+	Prev     string
+	Next     string
+	Filters  models.Filters
+	// FilterParams are the query parameters of the filters, kept by the navigation links.
+	FilterParams url.Values
+	BandMembers  []BandMemberOption
+}
+
+// ViewURL returns the link to another view of the same date, keeping the filters.
+func (c Calendar) ViewURL(view string) string {
+	return calendarURL(view, c.Date, c.FilterParams)
+}
+
+// CalendarHandler renders the concerts of every artist as a month, week or day calendar.
+// The view is selected by the "view" query parameter (month by default), the displayed period by "date"
+// (YYYY-MM-DD, by default the next concert or the last one), and the artists by the usual filters.
+func CalendarHandler(w http.ResponseWriter, r *http.Request) error {
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	filters := ParseFilters(r)
+	byDay := concertsByDay(FilterArtists(artists, filters))
+
+	view := r.URL.Query().Get("view")
+	if view != "week" && view != "day" {
+		view = "month"
+	}
+	// Without a date in the URL, the page shows the next concert, which depends on the current day as well.
+	var variant string
+	date, err := time.Parse(calendarDateLayout, r.URL.Query().Get("date"))
+	if err != nil {
+		date = defaultCalendarDate(byDay)
+		variant = date.Format(calendarDateLayout)
+	}
+	if notModifiedVariant(w, r, variant) {
+		return nil
+	}
+
+	calendar := buildCalendar(view, date, byDay)
+	calendar.Filters = filters
+	calendar.FilterParams = FiltersQuery(filters)
+	calendar.Prev = calendarURL(view, shiftCalendarDate(view, calendar.Date, -1), calendar.FilterParams)
+	calendar.Next = calendarURL(view, shiftCalendarDate(view, calendar.Date, 1), calendar.FilterParams)
+	for i := 1; i <= 8; i++ {
+		value := strconv.Itoa(i)
+		calendar.BandMembers = append(calendar.BandMembers, BandMemberOption{Value: value, Checked: contains(filters.BandMembers, value)})
+	}
+
+	renderTemplate(w, r, "calendar", TemplateData{
+		Title:    "Calendar - Groupie Tracker",
+		Calendar: calendar,
+	})
+	return nil
+}
+
+// concertsByDay groups the concerts of the artists by date ("2006-01-02"), each day ordered by artist name.
+func concertsByDay(artists []models.ArtistFull) map[string][]CalendarConcert {
+	byDay := make(map[string][]CalendarConcert)
+	for _, artist := range artists {
+		for _, concert := range artist.Relations.Concerts() {
+			key := concert.Date.Format(calendarDateLayout)
+			byDay[key] = append(byDay[key], CalendarConcert{
+				ArtistID:   artist.Artist.ID,
+				ArtistName: artist.Artist.Name,
+				Location:   concert.Location,
+			})
+		}
+	}
+	for _, concerts := range byDay {
+		sort.Slice(concerts, func(i, j int) bool { return concerts[i].ArtistName < concerts[j].ArtistName })
+	}
+	return byDay
+}
+
+// defaultCalendarDate returns the date of the next concert, or of the last one when none is upcoming.
+func defaultCalendarDate(byDay map[string][]CalendarConcert) time.Time {
+	today := time.Now().Format(calendarDateLayout)
+	var next, last string
+	for day := range byDay {
+		if day >= today && (next == "" || day < next) {
+			next = day
+		}
+		if day > last {
+			last = day
+		}
+	}
+	if next == "" {
+		next = last
+	}
+	date, err := time.Parse(calendarDateLayout, next)
+	if err != nil {
+		return time.Now().Truncate(24 * time.Hour)
+	}
+	return date
+}
+
+// buildCalendar lays out the days of the view containing date.
+func buildCalendar(view string, date time.Time, byDay map[string][]CalendarConcert) Calendar {
+	calendar := Calendar{View: view, Date: date}
+
+	var first, last time.Time
+	switch view {
+	case "day":
+		first, last = date, date
+		calendar.Heading = date.Format("Monday 2 January 2006")
+	case "week":
+		first = startOfWeek(date)
+		last = first.AddDate(0, 0, 6)
+		calendar.Heading = "Week of " + first.Format("2 January 2006")
+	default:
+		monthStart := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		first = startOfWeek(monthStart)
+		last = startOfWeek(monthStart.AddDate(0, 1, -1)).AddDate(0, 0, 6)
+		calendar.Heading = monthStart.Format("January 2006")
+	}
+
+	var week []CalendarDay
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		concerts := byDay[day.Format(calendarDateLayout)]
+		calendar.Concerts += len(concerts)
+		week = append(week, CalendarDay{
+			Date:     day,
+			Outside:  view == "month" && day.Month() != date.Month(),
+			Concerts: concerts,
+		})
+		if len(week) == 7 || view == "day" {
+			calendar.Weeks = append(calendar.Weeks, week)
+			week = nil
+		}
+	}
+	return calendar
+}
+
+// startOfWeek returns the Monday of the week containing date.
+func startOfWeek(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+// shiftCalendarDate moves date by n months, weeks or days depending on the view.
+func shiftCalendarDate(view string, date time.Time, n int) time.Time {
+	switch view {
+	case "day":
+		return date.AddDate(0, 0, n)
+	case "week":
+		return date.AddDate(0, 0, 7*n)
+	default:
+		// Move from the first of the month so that e.g. 31 January does not skip February.
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, n, 0)
+	}
+}
+
+// calendarURL returns the link to a calendar view with the given filters.
+func calendarURL(view string, date time.Time, filters url.Values) string {
+	q := url.Values{}
+	for key, values := range filters {
+		q[key] = values
+	}
+	q.Set("view", view)
+	q.Set("date", date.Format(calendarDateLayout))
+	return "/calendar?" + q.Encode()
+}
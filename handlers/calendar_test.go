@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildCalendar(t *testing.T) {
+	byDay := concertsByDay([]models.ArtistFull{
+		{Artist: models.Artist{ID: 1, Name: "Queen"}, Relations: models.Relations{DatesLocations: map[string][]string{
+			"london-uk": {"15-01-2020", "31-12-2019"}, "paris-france": {"16-01-2020"},
+		}}},
+		{Artist: models.Artist{ID: 2, Name: "AC/DC"}, Relations: models.Relations{DatesLocations: map[string][]string{
+			"london-uk": {"15-01-2020"},
+		}}},
+	})
+	wednesday := time.Date(2020, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		view     string
+		heading  string
+		first    string // First day shown.
+		weeks    int
+		days     int // Days per row.
+		concerts int
+		outside  int // Days of another month.
+	}{
+		{view: "month", heading: "January 2020", first: "2019-12-30", weeks: 5, days: 7, concerts: 4, outside: 4},
+		{view: "week", heading: "Week of 13 January 2020", first: "2020-01-13", weeks: 1, days: 7, concerts: 3},
+		{view: "day", heading: "Wednesday 15 January 2020", first: "2020-01-15", weeks: 1, days: 1, concerts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.view, func(t *testing.T) {
+			calendar := buildCalendar(tt.view, wednesday, byDay)
+			if calendar.Heading != tt.heading || calendar.Concerts != tt.concerts {
+				t.Errorf("heading %q with %d concerts, want %q with %d", calendar.Heading, calendar.Concerts, tt.heading, tt.concerts)
+			}
+			if len(calendar.Weeks) != tt.weeks {
+				t.Fatalf("got %d weeks, want %d", len(calendar.Weeks), tt.weeks)
+			}
+			if first := calendar.Weeks[0][0].Date.Format(calendarDateLayout); first != tt.first {
+				t.Errorf("first day = %s, want %s", first, tt.first)
+			}
+			outside := 0
+			for _, week := range calendar.Weeks {
+				if len(week) != tt.days {
+					t.Errorf("week of %d days, want %d", len(week), tt.days)
+				}
+				for _, day := range week {
+					if day.Outside {
+						outside++
+					}
+				}
+			}
+			if outside != tt.outside {
+				t.Errorf("%d days outside the month, want %d", outside, tt.outside)
+			}
+		})
+	}
+
+	// Each day lists its concerts by artist name.
+	day := buildCalendar("day", wednesday, byDay).Weeks[0][0]
+	if len(day.Concerts) != 2 || day.Concerts[0].ArtistName != "AC/DC" || day.Concerts[1].ArtistName != "Queen" {
+		t.Errorf("concerts of the day = %+v, want AC/DC then Queen", day.Concerts)
+	}
+}
+
+func TestShiftCalendarDate(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		view string
+		date time.Time
+		n    int
+		want time.Time
+	}{
+		{"day", date(2020, time.December, 31), 1, date(2021, time.January, 1)},
+		{"week", date(2020, time.January, 15), -1, date(2020, time.January, 8)},
+		{"month", date(2020, time.January, 31), 1, date(2020, time.February, 1)},
+		{"month", date(2020, time.January, 15), -1, date(2019, time.December, 1)},
+	}
+	for _, tt := range tests {
+		if got := shiftCalendarDate(tt.view, tt.date, tt.n); !got.Equal(tt.want) {
+			t.Errorf("shiftCalendarDate(%q, %s, %d) = %s, want %s", tt.view, tt.date.Format(calendarDateLayout), tt.n,
+				got.Format(calendarDateLayout), tt.want.Format(calendarDateLayout))
+		}
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	tests := []struct{ date, want string }{
+		{"2020-01-13", "2020-01-13"}, // Monday
+		{"2020-01-15", "2020-01-13"},
+		{"2020-01-19", "2020-01-13"}, // Sunday
+		{"2020-01-01", "2019-12-30"},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse(calendarDateLayout, tt.date)
+		if got := startOfWeek(date).Format(calendarDateLayout); got != tt.want {
+			t.Errorf("startOfWeek(%s) = %s, want %s", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarHandler(t *testing.T) {
+	if err := LoadTemplates(os.DirFS("../templates"), false); err != nil {
+		t.Fatal(err)
+	}
+	loadTourData(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // Expected in the page.
+	}{
+		{name: "default date is the last concert", query: "", want: []string{"January 2020", "Queen", "AC/DC"}},
+		{name: "week", query: "view=week&date=2020-01-01", want: []string{"Week of 30 December 2019", "Queen", "AC/DC"}},
+		{name: "day without concerts", query: "view=day&date=2020-01-06", want: []string{"Monday 6 January 2020"}},
+		{name: "unknown view shows the month", query: "view=year&date=2020-01-01", want: []string{"January 2020"}},
+		{
+			name:  "navigation keeps the filters",
+			query: "date=2020-01-01&creationMin=1960",
+			want:  []string{html.EscapeString(calendarURL("month", time.Date(2019, time.December, 1, 0, 0, 0, 0, time.UTC), url.Values{"creationMin": {"1960"}}))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandlerFunc(CalendarHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar?"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("the page does not contain %q", want)
+				}
+			}
+		})
+	}
+}
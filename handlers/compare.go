@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CompareHandler renders the side-by-side comparison of the artists given by the "ids" query parameter,
+// e.g. /compare?ids=1,2,3. With fewer than two IDs, only the form selecting the artists is shown.
+func CompareHandler(w http.ResponseWriter, r *http.Request) error {
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	// Copy the artists before sorting them for the form, the cached slice is shared.
+	data := TemplateData{
+		Title:   "Compare Artists - Groupie Tracker",
+		Artists: append([]models.ArtistFull(nil), artists...),
+	}
+	sort.Slice(data.Artists, func(i, j int) bool { return data.Artists[i].Artist.Name < data.Artists[j].Artist.Name })
+
+	ids, err := parseIDs(r)
+	// Preselect the requested artists in the form, one per selector.
+	data.Selected = make([]int, services.MaxCompared)
+	copy(data.Selected, ids)
+
+	// Invalid selections show the form again with the problem and a matching status.
+	status := http.StatusOK
+	switch {
+	case err != nil:
+		data.Message, status = err.Error(), http.StatusBadRequest
+	case len(ids) > 1:
+		data.Comparison, err = services.Compare(artists, ids)
+		switch {
+		case errors.Is(err, services.ErrArtistNotFound):
+			data.Message, status = err.Error(), http.StatusNotFound
+		case err != nil:
+			data.Message, status = err.Error(), http.StatusBadRequest
+		}
+	}
+
+	if status == http.StatusOK && notModified(w, r) {
+		return nil
+	}
+	renderPage(w, r, status, "compare", data)
+	return nil
+}
+
+// APICompareHandler returns the comparison of the artists given by the "ids" query parameter as JSON
+// (/api/v1/compare?ids=1,2).
+func APICompareHandler(w http.ResponseWriter, r *http.Request) error {
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	ids, err := parseIDs(r)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+	comparison, err := services.Compare(artists, ids)
+	switch {
+	case errors.Is(err, services.ErrArtistNotFound):
+		return notFound(err.Error())
+	case err != nil:
+		return badRequest(err.Error())
+	}
+	if notModified(w, r) {
+		return nil
+	}
+	writeJSON(w, r, comparison)
+	return nil
+}
+
+// parseIDs reads the artist IDs of the "ids" query parameter, given as a comma-separated list
+// or as repeated parameters. Empty values, such as unselected artists of the form, are ignored.
+func parseIDs(r *http.Request) ([]int, error) {
+	var ids []int
+	for _, value := range r.URL.Query()["ids"] {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.Atoi(part)
+			if err != nil || id <= 0 {
+				return nil, errors.New("invalid artist ID: " + part)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
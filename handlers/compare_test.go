@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"groopie_local/internal/testutil"
+	"groopie_local/services"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseIDs(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []int
+		err   bool
+	}{
+		{query: "ids=1,2,3", want: []int{1, 2, 3}},
+		{query: "ids=1&ids=2", want: []int{1, 2}},
+		{query: "ids=1,+2,,&ids=", want: []int{1, 2}},
+		{query: ""},
+		{query: "ids=1,x", err: true},
+		{query: "ids=0", err: true},
+		{query: "ids=-1", err: true},
+	}
+	for _, tt := range tests {
+		ids, err := parseIDs(httptest.NewRequest(http.MethodGet, "/compare?"+tt.query, nil))
+		if (err != nil) != tt.err || !slices.Equal(ids, tt.want) {
+			t.Errorf("parseIDs(%q) = %v, %v, want %v (error: %v)", tt.query, ids, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCompareHandlers(t *testing.T) {
+	if err := LoadTemplates(os.DirFS("../templates"), false); err != nil {
+		t.Fatal(err)
+	}
+	api := loadTestData(t)
+	responses := maps.Clone(testutil.APIResponses)
+	responses["artists"] = `[{"id":1,"name":"Queen","members":["Freddie Mercury"]},{"id":2,"name":"AC/DC","members":["Angus Young"]}]`
+	responses["relation"] = `{"index":[{"id":1,"datesLocations":{"london-uk":["01-01-2020"]}},{"id":2,"datesLocations":{"london-uk":["01-01-2020"]}}]}`
+	api.SetResponses(responses)
+	if err := services.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+		query   string
+		status  int
+		body    string // Expected in the response.
+	}{
+		{name: "page", handler: CompareHandler, query: "ids=1,2", status: http.StatusOK, body: "AC/DC"},
+		{name: "page form only", handler: CompareHandler, query: "ids=1", status: http.StatusOK},
+		{name: "page invalid ID", handler: CompareHandler, query: "ids=x", status: http.StatusBadRequest, body: "invalid artist ID: x"},
+		{name: "page unknown artist", handler: CompareHandler, query: "ids=1,9", status: http.StatusNotFound, body: "artist not found: 9"},
+		{name: "page too many", handler: CompareHandler, query: "ids=1,2,3,4,5", status: http.StatusBadRequest, body: "select between 2 and 4"},
+		{name: "api", handler: APICompareHandler, query: "ids=1,2", status: http.StatusOK, body: `"sharedByAll":["london-uk"]`},
+		{name: "api one artist", handler: APICompareHandler, query: "ids=1", status: http.StatusBadRequest},
+		{name: "api invalid ID", handler: APICompareHandler, query: "ids=x", status: http.StatusBadRequest},
+		{name: "api unknown artist", handler: APICompareHandler, query: "ids=1,9", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/compare?"+tt.query, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("the response does not contain %q", tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Error is an error with the HTTP status to respond with and a message that can be shown to the user.
+// The wrapped error, if any, is the cause: it is logged but never sent to the client.
+type Error struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.Status)
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// badRequest returns a 400 Bad Request error with the given message.
+func badRequest(message string) error {
+	return &Error{Status: http.StatusBadRequest, Message: message}
+}
+
+// unauthorized returns a 401 Unauthorized error with the given message.
+func unauthorized(message string) error {
+	return &Error{Status: http.StatusUnauthorized, Message: message}
+}
+
+// forbidden returns a 403 Forbidden error with the given message.
+func forbidden(message string) error {
+	return &Error{Status: http.StatusForbidden, Message: message}
+}
+
+// notFound returns a 404 Not Found error with the given message, or the generic "not found" page when it is empty.
+func notFound(message string) error {
+	return &Error{Status: http.StatusNotFound, Message: message}
+}
+
+// methodNotAllowed returns a 405 Method Not Allowed error.
+func methodNotAllowed() error {
+	return &Error{Status: http.StatusMethodNotAllowed, Message: "Method not allowed"}
+}
+
+// unavailable returns a 503 Service Unavailable error for when the artist data cannot be loaded, e.g. the API is down.
+func unavailable(err error) error {
+	return &Error{Status: http.StatusServiceUnavailable, Message: "Unable to load data. Please try again later.", Err: err}
+}
+
+// internalError returns a 500 Internal Server Error with the given message, caused by err.
+func internalError(message string, err error) error {
+	return &Error{Status: http.StatusInternalServerError, Message: message, Err: err}
+}
+
+// HandlerFunc is an HTTP handler that returns its errors instead of writing them, so they are all reported
+// the same way: with the status and message of an *Error, or as a 500 Internal Server Error for any other error,
+// as an HTML page or JSON depending on the request. A handler returning an error must not have written the response.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		writeError(w, r, err)
+	}
+}
+
+// writeError logs err and sends it to the client, as JSON if the client asked for it or as the error page otherwise.
+// Server errors are logged as errors, client errors only as information.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var httpErr *Error
+	if !errors.As(err, &httpErr) {
+		httpErr = &Error{Status: http.StatusInternalServerError, Message: "Something went wrong. Please try again later.", Err: err}
+	}
+
+	level := slog.LevelInfo
+	if httpErr.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(r.Context(), level, "Request failed", "status", httpErr.Status, "error", err)
+
+	if wantsJSON(r) {
+		message := httpErr.Message
+		if message == "" {
+			message = http.StatusText(httpErr.Status)
+		}
+		writeJSONError(w, r, httpErr.Status, message)
+		return
+	}
+	renderError(w, r, httpErr.Status, httpErr.Message)
+}
+
+// writeJSONError sends an error as a JSON object {"error": message} with the given status code.
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	clearValidators(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, r, map[string]string{"error": message})
+}
+
+// wantsJSON reports whether errors should be sent to the client as JSON rather than as an HTML page:
+// for the JSON API and the JSON exports, and for clients preferring JSON to HTML in their Accept header.
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasSuffix(r.URL.Path, ".json") || strings.HasSuffix(r.URL.Path, ".geojson") {
+		return true
+	}
+	return acceptQuality(r, "application/json") > acceptQuality(r, "text/html")
+}
+
+// acceptQuality returns the quality the Accept header of the request gives to the media type,
+// from 0 (not acceptable) to 1, taking wildcards such as "text/*" and "*/*" into account.
+// Without an Accept header, every media type is acceptable.
+func acceptQuality(r *http.Request, mediaType string) float64 {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return 1
+	}
+
+	typ, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		accepted, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		// The most specific matching range applies: "text/html" over "text/*" over "*/*".
+		var s int
+		switch accepted {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				q = 0
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
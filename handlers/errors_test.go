@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"groopie_local/logging"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptQuality(t *testing.T) {
+	tests := []struct {
+		accept    string
+		mediaType string
+		want      float64
+	}{
+		{"", "text/html", 1},
+		{"text/html", "text/html", 1},
+		{"text/html", "application/json", 0},
+		{"application/json;q=0.5", "application/json", 0.5},
+		{"text/*;q=0.3, text/html;q=0.7", "text/html", 0.7},
+		{"text/html;q=0.7, text/*;q=0.3", "text/html", 0.7},
+		{"text/*;q=0.3, */*;q=0.1", "text/plain", 0.3},
+		{"*/*;q=0.1", "application/json", 0.1},
+		{"application/json;q=oops", "application/json", 0},
+		{"not a media type, application/json", "application/json", 1},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := acceptQuality(r, tt.mediaType); got != tt.want {
+			t.Errorf("acceptQuality(%q, %q) = %v, want %v", tt.accept, tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+func TestWantsJSON(t *testing.T) {
+	tests := []struct {
+		path   string
+		accept string
+		want   bool
+	}{
+		{"/artist/1", "", false},
+		{"/artist/1", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"/search", "application/json", true},
+		{"/search", "application/json, text/html;q=0.9", true},
+		{"/search", "*/*", false},
+		{"/api/v1/artists", "", true},
+		{"/api/v1/artists", "text/html", true},
+		{"/festivals.json", "", true},
+		{"/tours.geojson", "", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := wantsJSON(r); got != tt.want {
+			t.Errorf("wantsJSON(%s, Accept %q) = %v, want %v", tt.path, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"bad request", badRequest("Invalid artist ID"), http.StatusBadRequest, `{"error":"Invalid artist ID"}` + "\n"},
+		{"not found without message", notFound(""), http.StatusNotFound, `{"error":"Not Found"}` + "\n"},
+		{"unexpected error", http.ErrBodyNotAllowed, http.StatusInternalServerError, `{"error":"Something went wrong. Please try again later."}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, httptest.NewRequest(http.MethodGet, "/api/v1/artists/x", nil), tt.err)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("writeError() = %d %q, want %d %q", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+			if cache := w.Header().Get("Cache-Control"); cache != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", cache)
+			}
+		})
+	}
+}
+
+func TestErrorLogsCarryRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		write  func(w http.ResponseWriter, r *http.Request)
+		status int
+		msg    string
+	}{
+		{
+			name:   "handler error",
+			write:  func(w http.ResponseWriter, r *http.Request) { writeError(w, r, unavailable(http.ErrServerClosed)) },
+			status: http.StatusServiceUnavailable,
+			msg:    "Request failed",
+		},
+		{
+			name:   "unknown template",
+			write:  func(w http.ResponseWriter, r *http.Request) { renderTemplate(w, r, "no-such-page", TemplateData{}) },
+			status: http.StatusInternalServerError,
+			msg:    "Error parsing template",
+		},
+		{
+			name:   "unencodable XML",
+			write:  func(w http.ResponseWriter, r *http.Request) { writeXML(w, r, "application/xml", make(chan int)) },
+			status: http.StatusOK,
+			msg:    "Error encoding XML response",
+		},
+		{
+			name:   "unencodable JSON",
+			write:  func(w http.ResponseWriter, r *http.Request) { writeJSON(w, r, make(chan int)) },
+			status: http.StatusInternalServerError,
+			msg:    "Error encoding JSON response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := slog.Default()
+			t.Cleanup(func() { slog.SetDefault(previous) })
+			var buf bytes.Buffer
+			if err := logging.Setup(&buf, "info", "json"); err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/home", nil)
+			r = r.WithContext(logging.WithRequestID(context.Background(), "req-1"))
+			w := httptest.NewRecorder()
+			tt.write(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			var record struct {
+				Msg       string `json:"msg"`
+				RequestID string `json:"request_id"`
+			}
+			if err := json.NewDecoder(&buf).Decode(&record); err != nil {
+				t.Fatalf("no log record: %v", err)
+			}
+			if record.Msg != tt.msg || record.RequestID != "req-1" {
+				t.Errorf("log record = %q with request_id %q, want %q with req-1", record.Msg, record.RequestID, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"groopie_local/models"
+	"groopie_local/services"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sseHeartbeat is the interval of the comment lines that keep idle event streams open through proxies.
+const sseHeartbeat = 30 * time.Second
+
+// sseMessage is a single Server-Sent Event.
+type sseMessage struct {
+	Event    string
+	ArtistID int // Zero for events that concern every client.
+	Data     []byte
+}
+
+// RefreshEvent is the payload of the "refresh" event sent after every cache refresh.
+type RefreshEvent struct {
+	Time        time.Time `json:"time"`
+	ArtistCount int       `json:"artistCount"`
+	Changed     bool      `json:"changed"`
+	ArtistIDs   []int     `json:"artistIds"`
+}
+
+// ArtistEvent is the payload of the "artist" event sent for each artist changed by a refresh.
+// Artist holds the current data, or is nil when the artist was removed.
+type ArtistEvent struct {
+	ArtistID   int                    `json:"artistId"`
+	ArtistName string                 `json:"artistName"`
+	Changes    []services.ChangeEvent `json:"changes"`
+	Artist     *models.ArtistFull     `json:"artist"`
+}
+
+// eventBroker fans out events to the connected SSE clients.
+type eventBroker struct {
+	mu      sync.Mutex
+	clients map[chan sseMessage]bool
+	// done is closed when the server shuts down so open streams end.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// broker is the shared broker used by EventsHandler and BroadcastChanges.
+var broker = &eventBroker{clients: make(map[chan sseMessage]bool), done: make(chan struct{})}
+
+// CloseEventStreams ends every open event stream. It is registered with the server's
+// RegisterOnShutdown so long-lived streams do not hold up a graceful shutdown.
+func CloseEventStreams() {
+	broker.closeOnce.Do(func() { close(broker.done) })
+}
+
+// subscribe registers a new client and returns its message channel.
+func (b *eventBroker) subscribe() chan sseMessage {
+	ch := make(chan sseMessage, 16)
+	b.mu.Lock()
+	b.clients[ch] = true
+	b.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes a client registered with subscribe.
+func (b *eventBroker) unsubscribe(ch chan sseMessage) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+// publish sends the message to every client. Clients that are too slow to keep up miss it
+// rather than blocking the others.
+func (b *eventBroker) publish(msg sseMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// BroadcastChanges pushes a refresh event and one artist event per changed artist to the
+// connected clients. It is registered with services.OnChange at startup.
+func BroadcastChanges(diff services.Diff) {
+	// Group the changes by artist, keeping the order of first appearance.
+	var ids []int
+	changes := make(map[int][]services.ChangeEvent)
+	for _, event := range diff.Events() {
+		if _, ok := changes[event.ArtistID]; !ok {
+			ids = append(ids, event.ArtistID)
+		}
+		changes[event.ArtistID] = append(changes[event.ArtistID], event)
+	}
+
+	// Artist events carry the data of the refresh that produced the diff.
+	current := make(map[int]models.ArtistFull, len(diff.Artists))
+	for _, artist := range diff.Artists {
+		current[artist.Artist.ID] = artist
+	}
+
+	ctx := context.Background()
+	for _, id := range ids {
+		event := ArtistEvent{ArtistID: id, ArtistName: changes[id][0].ArtistName, Changes: changes[id]}
+		if artist, ok := current[id]; ok {
+			event.Artist = &artist
+		}
+		broker.publishJSON(ctx, "artist", id, event)
+	}
+
+	broker.publishJSON(ctx, "refresh", 0, RefreshEvent{
+		Time:        diff.Time,
+		ArtistCount: diff.ArtistCount,
+		Changed:     !diff.IsEmpty(),
+		ArtistIDs:   ids,
+	})
+}
+
+// publishJSON encodes the payload and publishes it as the named event. Errors are logged with ctx.
+func (b *eventBroker) publishJSON(ctx context.Context, event string, artistID int, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding event", "event", event, "error", err)
+		return
+	}
+	b.publish(sseMessage{Event: event, ArtistID: artistID, Data: data})
+}
+
+// EventsHandler streams refresh and artist change events as Server-Sent Events.
+// With ?artist={id}, only the artist events of that artist are sent, along with refresh events.
+func EventsHandler(w http.ResponseWriter, r *http.Request) error {
+	artistID := 0
+	if idStr := r.URL.Query().Get("artist"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			return badRequest("Invalid artist ID")
+		}
+		artistID = id
+	}
+
+	// The stream outlives the server's write timeout, so lift it for this response.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(r.Context(), "Error disabling write deadline for event stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	// Ask the browser to wait before reconnecting if the stream drops.
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Streaming unsupported", "error", err)
+		return nil
+	}
+
+	ch := broker.subscribe()
+	defer broker.unsubscribe(ch)
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-broker.done:
+			return nil
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case msg := <-ch:
+			if artistID != 0 && msg.ArtistID != 0 && msg.ArtistID != artistID {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Event, msg.Data)
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
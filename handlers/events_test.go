@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForClients waits until n event streams are subscribed to the broker.
+func waitForClients(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		broker.mu.Lock()
+		clients := len(broker.clients)
+		broker.mu.Unlock()
+		if clients == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d clients subscribed, want %d", clients, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	b := &eventBroker{clients: make(map[chan sseMessage]bool), done: make(chan struct{})}
+	first, second := b.subscribe(), b.subscribe()
+	gone := b.subscribe()
+	b.unsubscribe(gone)
+
+	// A client that stopped reading misses messages instead of blocking the others.
+	for i := 0; i < cap(first)+5; i++ {
+		b.publish(sseMessage{Event: "refresh"})
+	}
+	if len(first) != cap(first) || len(second) != cap(second) {
+		t.Errorf("queued %d and %d messages, want %d each", len(first), len(second), cap(first))
+	}
+	if len(gone) != 0 {
+		t.Errorf("an unsubscribed client received %d messages", len(gone))
+	}
+}
+
+func TestEventsHandlerInvalidArtist(t *testing.T) {
+	for _, id := range []string{"abc", "0", "-3", "1.5"} {
+		w := httptest.NewRecorder()
+		HandlerFunc(EventsHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?artist="+id, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("/events?artist=%s: status %d, want 400", id, w.Code)
+		}
+	}
+}
+
+// sseEvent is an event read from a stream.
+type sseEvent struct {
+	name, data string
+}
+
+// readEvents reads events from an SSE stream until a refresh event, which closes every broadcast.
+func readEvents(t *testing.T, scanner *bufio.Scanner) []sseEvent {
+	t.Helper()
+	var (
+		events  []sseEvent
+		current sseEvent
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		case line == "" && current.name != "":
+			events = append(events, current)
+			if current.name == "refresh" {
+				return events
+			}
+			current = sseEvent{}
+		}
+	}
+	t.Fatalf("stream ended before the refresh event: %v", scanner.Err())
+	return nil
+}
+
+func TestBroadcastChanges(t *testing.T) {
+	queen := models.ArtistFull{Artist: models.Artist{ID: 1, Name: "Queen"}}
+	acdc := models.ArtistFull{Artist: models.Artist{ID: 2, Name: "AC/DC"}}
+	abba := models.ArtistFull{Artist: models.Artist{ID: 3, Name: "ABBA"}}
+	diff := services.DiffData([]models.ArtistFull{queen, abba}, []models.ArtistFull{queen, acdc})
+	// The event data comes from the diff; the cache is never loaded in this test.
+	diff.Artists = []models.ArtistFull{queen, acdc}
+
+	tests := []struct {
+		name    string
+		query   string
+		artists []int // IDs of the artist events received, in order.
+	}{
+		{name: "every artist", artists: []int{2, 3}},
+		{name: "one artist", query: "?artist=2", artists: []int{2}},
+		{name: "unchanged artist", query: "?artist=1"},
+	}
+
+	server := httptest.NewServer(HandlerFunc(EventsHandler))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "/events" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				resp.Body.Close()
+				waitForClients(t, 0)
+			}()
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+			}
+			scanner := bufio.NewScanner(resp.Body)
+			waitForClients(t, 1)
+
+			BroadcastChanges(diff)
+			events := readEvents(t, scanner)
+
+			var got []int
+			for _, event := range events[:len(events)-1] {
+				var payload ArtistEvent
+				if err := json.Unmarshal([]byte(event.data), &payload); err != nil || event.name != "artist" {
+					t.Fatalf("event %s %s is not an artist event: %v", event.name, event.data, err)
+				}
+				got = append(got, payload.ArtistID)
+				switch payload.ArtistID {
+				case 2:
+					if payload.Artist == nil || payload.Artist.Artist.Name != "AC/DC" || payload.Changes[0].Type != services.EventArtistAdded {
+						t.Errorf("added artist event = %+v, want the AC/DC data", payload)
+					}
+				case 3:
+					if payload.Artist != nil || payload.Changes[0].Type != services.EventArtistRemoved {
+						t.Errorf("removed artist event = %+v, want no data", payload)
+					}
+				}
+			}
+			if !slices.Equal(got, tt.artists) {
+				t.Errorf("artist events for %v, want %v", got, tt.artists)
+			}
+
+			var refresh RefreshEvent
+			if err := json.Unmarshal([]byte(events[len(events)-1].data), &refresh); err != nil {
+				t.Fatal(err)
+			}
+			if !refresh.Changed || refresh.ArtistCount != 2 || !slices.Equal(refresh.ArtistIDs, []int{2, 3}) {
+				t.Errorf("refresh event = %+v", refresh)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"groopie_local/services"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// feedItem is a format-neutral feed entry, rendered as either Atom or RSS.
+type feedItem struct {
+	ID      string
+	Title   string
+	Link    string
+	Summary string
+	Updated time.Time
+}
+
+// atomFeed is the root element of an Atom 1.0 feed.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink is an Atom link element.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// atomEntry is a single Atom entry.
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary"`
+}
+
+// rssFeed is the root element of an RSS 2.0 feed.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel describes the RSS feed and holds its items.
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssItem is a single RSS item.
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+// rssGUID is an RSS item identifier that is not a URL.
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// FeedHandler serves the global feeds of newly added artists and newly announced concerts
+// at /feed.atom and /feed.rss.
+func FeedHandler(w http.ResponseWriter, r *http.Request) error {
+	base := baseURL(r)
+	items := buildFeedItems(base, 0)
+
+	switch r.URL.Path {
+	case "/feed.atom":
+		writeAtomFeed(w, r, "Groupie Tracker updates", base+r.URL.Path, base+"/home", items)
+	case "/feed.rss":
+		writeRSSFeed(w, r, "Groupie Tracker updates", base+"/home", items)
+	default:
+		return notFound("")
+	}
+	return nil
+}
+
+// writeArtistFeed serves the feed of a single artist in the given format ("atom" or "rss").
+func writeArtistFeed(w http.ResponseWriter, r *http.Request, artistID int, artistName, format string) {
+	base := baseURL(r)
+	items := buildFeedItems(base, artistID)
+	title := artistName + " - Groupie Tracker updates"
+	link := fmt.Sprintf("%s/artist/%d", base, artistID)
+
+	if format == "atom" {
+		writeAtomFeed(w, r, title, base+r.URL.Path, link, items)
+		return
+	}
+	writeRSSFeed(w, r, title, link, items)
+}
+
+// buildFeedItems turns the recorded refresh diffs into feed items, newest first.
+// When artistID is non-zero, only the changes concerning that artist are included.
+func buildFeedItems(base string, artistID int) []feedItem {
+	var items []feedItem
+	for _, diff := range services.DiffHistory() {
+		stamp := diff.Time.UTC().Format("20060102T150405Z")
+
+		for _, artist := range diff.AddedArtists {
+			if artistID != 0 && artist.ID != artistID {
+				continue
+			}
+			items = append(items, feedItem{
+				ID:      fmt.Sprintf("%s/artist/%d#added-%s", base, artist.ID, stamp),
+				Title:   "New artist: " + artist.Name,
+				Link:    fmt.Sprintf("%s/artist/%d", base, artist.ID),
+				Summary: fmt.Sprintf("%s (formed %d) was added.", artist.Name, artist.CreationDate),
+				Updated: diff.Time,
+			})
+		}
+
+		for _, concert := range diff.AddedConcerts {
+			if artistID != 0 && concert.ArtistID != artistID {
+				continue
+			}
+			items = append(items, feedItem{
+				ID:      fmt.Sprintf("%s/artist/%d#concert-%s-%s", base, concert.ArtistID, url.PathEscape(concert.Location), url.PathEscape(concert.Date)),
+				Title:   fmt.Sprintf("New concert: %s in %s on %s", concert.ArtistName, services.LocationQuery(concert.Location), concert.Date),
+				Link:    fmt.Sprintf("%s/artist/%d", base, concert.ArtistID),
+				Summary: fmt.Sprintf("%s announced a concert in %s on %s.", concert.ArtistName, services.LocationQuery(concert.Location), concert.Date),
+				Updated: diff.Time,
+			})
+		}
+	}
+	return items
+}
+
+// writeAtomFeed encodes the items as an Atom feed.
+func writeAtomFeed(w http.ResponseWriter, r *http.Request, title, self, link string, items []feedItem) {
+	feed := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   title,
+		ID:      self,
+		Updated: feedUpdated(items).Format(time.RFC3339),
+		Links:   []atomLink{{Href: self, Rel: "self"}, {Href: link}},
+	}
+	for _, item := range items {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   item.Title,
+			ID:      item.ID,
+			Updated: item.Updated.Format(time.RFC3339),
+			Link:    atomLink{Href: item.Link},
+			Summary: item.Summary,
+		})
+	}
+
+	writeXML(w, r, "application/atom+xml", feed)
+}
+
+// writeRSSFeed encodes the items as an RSS 2.0 feed.
+func writeRSSFeed(w http.ResponseWriter, r *http.Request, title, link string, items []feedItem) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         title,
+			Link:          link,
+			Description:   "Newly added artists and newly announced concerts.",
+			LastBuildDate: feedUpdated(items).Format(time.RFC1123Z),
+		},
+	}
+	for _, item := range items {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Updated.Format(time.RFC1123Z),
+			Description: item.Summary,
+		})
+	}
+
+	writeXML(w, r, "application/rss+xml", feed)
+}
+
+// feedUpdated returns the time of the newest item, or the current time for an empty feed.
+func feedUpdated(items []feedItem) time.Time {
+	if len(items) == 0 {
+		return time.Now()
+	}
+	return items[0].Updated
+}
+
+// writeXML writes an XML document with the given content type.
+func writeXML(w http.ResponseWriter, r *http.Request, contentType string, v interface{}) {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing XML response", "error", err)
+		return
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding XML response", "error", err)
+	}
+}
+
+// publicURL is the configured address of the server, without a trailing slash, or "" to use the request's.
+var publicURL string
+
+// SetPublicURL sets the address clients reach the server at, used for the absolute links of the feeds.
+// It must be called before the server starts.
+func SetPublicURL(address string) {
+	publicURL = strings.TrimSuffix(address, "/")
+}
+
+// baseURL returns the address of the server for absolute links, e.g. "http://localhost:8080".
+// Without a configured public URL, it is the scheme and host the request was made to. These come from the
+// Host and X-Forwarded-Proto headers, which the client controls: the server is then assumed to be reached
+// directly or through a reverse proxy that sets both, and a client forging them only affects its own response.
+func baseURL(r *http.Request) string {
+	if publicURL != "" {
+		return publicURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"groopie_local/internal/testutil"
+	"groopie_local/services"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// loadFeedChanges loads the test data, then refreshes it with a new artist and new concerts,
+// and returns how many feed items the earlier refreshes produced, which are listed after the new ones.
+func loadFeedChanges(t *testing.T) int {
+	t.Helper()
+	api := loadTestData(t)
+	before := len(buildFeedItems("", 0))
+
+	updated := maps.Clone(testutil.APIResponses)
+	updated["artists"] = `[{"id":1,"name":"Queen","members":["Freddie Mercury"]},{"id":2,"name":"AC/DC","creationDate":1973,"members":["Angus Young"]}]`
+	updated["relation"] = `{"index":[` +
+		`{"id":1,"datesLocations":{"london-uk":["01-01-2020"],"saint_étienne-france":["02-02-2021"]}},` +
+		`{"id":2,"datesLocations":{"sydney-australia":["03-03-2021"]}}]}`
+	api.SetResponses(updated)
+	if err := services.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return before
+}
+
+func TestBuildFeedItems(t *testing.T) {
+	before := loadFeedChanges(t)
+
+	tests := []struct {
+		name     string
+		artistID int
+		want     []feedItem // Without the update time.
+	}{
+		{
+			name: "every artist",
+			want: []feedItem{
+				{Title: "New artist: AC/DC", Link: "http://example.com/artist/2", Summary: "AC/DC (formed 1973) was added."},
+				{
+					ID:      "http://example.com/artist/1#concert-saint_%C3%A9tienne-france-02-02-2021",
+					Title:   "New concert: Queen in saint étienne, france on 02-02-2021",
+					Link:    "http://example.com/artist/1",
+					Summary: "Queen announced a concert in saint étienne, france on 02-02-2021.",
+				},
+				{
+					ID:      "http://example.com/artist/2#concert-sydney-australia-03-03-2021",
+					Title:   "New concert: AC/DC in sydney, australia on 03-03-2021",
+					Link:    "http://example.com/artist/2",
+					Summary: "AC/DC announced a concert in sydney, australia on 03-03-2021.",
+				},
+			},
+		},
+		{
+			name:     "one artist",
+			artistID: 1,
+			want: []feedItem{{
+				ID:      "http://example.com/artist/1#concert-saint_%C3%A9tienne-france-02-02-2021",
+				Title:   "New concert: Queen in saint étienne, france on 02-02-2021",
+				Link:    "http://example.com/artist/1",
+				Summary: "Queen announced a concert in saint étienne, france on 02-02-2021.",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := buildFeedItems("http://example.com", tt.artistID)
+			if tt.artistID == 0 {
+				items = items[:len(items)-before]
+			} else {
+				items = items[:min(len(items), len(tt.want))]
+			}
+			if len(items) != len(tt.want) {
+				t.Fatalf("got %d new items, want %d: %+v", len(items), len(tt.want), items)
+			}
+			for i, item := range items {
+				want := tt.want[i]
+				if want.ID == "" {
+					// Artist entries are identified by the time of the refresh that added them.
+					if !strings.HasPrefix(item.ID, want.Link+"#added-") {
+						t.Errorf("item %d: ID = %q, want an added- fragment", i, item.ID)
+					}
+					want.ID = item.ID
+				}
+				want.Updated = item.Updated
+				if item != want {
+					t.Errorf("item %d = %+v, want %+v", i, item, want)
+				}
+				if item.Updated.IsZero() {
+					t.Errorf("item %d has no update time", i)
+				}
+			}
+		})
+	}
+}
+
+func TestFeedHandler(t *testing.T) {
+	loadFeedChanges(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		publicURL   string
+		headers     map[string]string
+		status      int
+		contentType string
+		base        string // Expected start of every link.
+	}{
+		{name: "atom", path: "/feed.atom", status: http.StatusOK, contentType: "application/atom+xml; charset=utf-8", base: "http://example.com"},
+		{name: "rss", path: "/feed.rss", status: http.StatusOK, contentType: "application/rss+xml; charset=utf-8", base: "http://example.com"},
+		{name: "forwarded https", path: "/feed.atom", headers: map[string]string{"X-Forwarded-Proto": "https"}, status: http.StatusOK, contentType: "application/atom+xml; charset=utf-8", base: "https://example.com"},
+		{name: "public URL", path: "/feed.rss", publicURL: "https://groupie.example.org/", headers: map[string]string{"X-Forwarded-Proto": "http", "Host": "evil.example"}, status: http.StatusOK, contentType: "application/rss+xml; charset=utf-8", base: "https://groupie.example.org"},
+		{name: "unknown format", path: "/feed.json", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPublicURL(tt.publicURL)
+			defer SetPublicURL("")
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			if host := tt.headers["Host"]; host != "" {
+				r.Host = host
+			}
+			w := httptest.NewRecorder()
+			HandlerFunc(FeedHandler).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if !strings.HasPrefix(w.Body.String(), xml.Header) {
+				t.Error("the feed does not start with the XML declaration")
+			}
+
+			var links []string
+			if strings.HasSuffix(tt.path, ".atom") {
+				var feed atomFeed
+				if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+					t.Fatal(err)
+				}
+				if feed.ID != tt.base+tt.path || len(feed.Links) != 2 || feed.Links[0].Rel != "self" || feed.Links[0].Href != feed.ID {
+					t.Errorf("feed ID %q and links %+v, want %s as the self link", feed.ID, feed.Links, tt.base+tt.path)
+				}
+				if len(feed.Entries) < 3 || feed.Entries[0].Title != "New artist: AC/DC" {
+					t.Fatalf("entries = %+v, want the new artist first", feed.Entries)
+				}
+				links = append(links, feed.ID)
+				for _, entry := range feed.Entries {
+					links = append(links, entry.ID, entry.Link.Href)
+				}
+			} else {
+				var feed rssFeed
+				if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+					t.Fatal(err)
+				}
+				if feed.Version != "2.0" || feed.Channel.Link != tt.base+"/home" {
+					t.Errorf("version %q and link %q, want 2.0 and %s/home", feed.Version, feed.Channel.Link, tt.base)
+				}
+				items := feed.Channel.Items
+				if len(items) < 3 || items[0].Title != "New artist: AC/DC" || items[0].GUID.IsPermaLink {
+					t.Fatalf("items = %+v, want the new artist first with a GUID that is not a link", items)
+				}
+				for _, item := range items {
+					links = append(links, item.GUID.Value, item.Link)
+				}
+			}
+			for _, link := range links {
+				if !strings.HasPrefix(link, tt.base+"/") {
+					t.Errorf("link %q does not start with %s", link, tt.base)
+				}
+			}
+		})
+	}
+}
+
+func TestArtistFeed(t *testing.T) {
+	loadFeedChanges(t)
+
+	w := httptest.NewRecorder()
+	HandlerFunc(ArtistHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/artist/2/feed.rss", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var feed rssFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.Title != "AC/DC - Groupie Tracker updates" || feed.Channel.Link != "http://example.com/artist/2" {
+		t.Errorf("channel = %q, %q", feed.Channel.Title, feed.Channel.Link)
+	}
+	for _, item := range feed.Channel.Items {
+		if item.Link != "http://example.com/artist/2" {
+			t.Errorf("item %q of another artist in the AC/DC feed", item.Title)
+		}
+	}
+	if len(feed.Channel.Items) < 2 {
+		t.Errorf("got %d items, want the new artist and its concert", len(feed.Channel.Items))
+	}
+}
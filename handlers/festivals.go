@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"groopie_local/services"
+	"net/http"
+	"strings"
+)
+
+// FestivalsHandler serves the events where several artists played at the same location on the same or nearby dates.
+// /festivals renders an HTML page and /festivals.json returns the events as JSON.
+// The "q" query parameter keeps the events whose location or artists contain it, and "type" keeps only
+// shared bills ("shared", a single date) or festivals ("festival", several dates).
+func FestivalsHandler(w http.ResponseWriter, r *http.Request) error {
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	if notModified(w, r) {
+		return nil
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	eventType := r.URL.Query().Get("type")
+	events := filterEvents(services.FindEvents(artists), query, eventType)
+
+	switch r.URL.Path {
+	case "/festivals":
+		renderTemplate(w, r, "festivals", TemplateData{
+			Title:       "Festivals & Shared Bills - Groupie Tracker",
+			Events:      events,
+			SearchQuery: query,
+			SearchType:  eventType,
+		})
+	case "/festivals.json":
+		if events == nil {
+			events = []services.Event{}
+		}
+		writeJSON(w, r, events)
+	default:
+		return notFound("")
+	}
+	return nil
+}
+
+// filterEvents keeps the events matching the search query and event type.
+func filterEvents(events []services.Event, query, eventType string) []services.Event {
+	query = strings.ToLower(query)
+	var filtered []services.Event
+	for _, event := range events {
+		if eventType == "shared" && !event.SameDay() || eventType == "festival" && event.SameDay() {
+			continue
+		}
+		if query != "" && !eventMatches(event, query) {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	return filtered
+}
+
+// eventMatches reports whether the event's location or one of its artists contains the lowercase query.
+func eventMatches(event services.Event, query string) bool {
+	if strings.Contains(strings.ToLower(event.Location), query) || strings.Contains(services.LocationQuery(event.Location), query) {
+		return true
+	}
+	for _, appearance := range event.Appearances {
+		if strings.Contains(strings.ToLower(appearance.ArtistName), query) {
+			return true
+		}
+	}
+	return false
+}
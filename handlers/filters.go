@@ -3,12 +3,16 @@ package handlers
 import (
 	"groopie_local/models"
 	"net/http"
+	"net/url"
 	"strconv"
 )
 
 func ParseFilters(r *http.Request) models.Filters {
-	q := r.URL.Query()
+	return ParseFiltersQuery(r.URL.Query())
+}
 
+// ParseFiltersQuery reads the filters from query parameters, falling back to the default ranges.
+func ParseFiltersQuery(q url.Values) models.Filters {
 	return models.Filters{
 		SearchQuery:          q.Get("search"),
 		SearchType:           q.Get("searchType"),
@@ -21,6 +25,36 @@ func ParseFilters(r *http.Request) models.Filters {
 	}
 }
 
+// FiltersQuery is the inverse of ParseFiltersQuery: it returns the query parameters selecting
+// the given filters, leaving out the values that are equal to the defaults.
+func FiltersQuery(filters models.Filters) url.Values {
+	q := url.Values{}
+	if filters.SearchQuery != "" {
+		q.Set("search", filters.SearchQuery)
+	}
+	if filters.SearchType != "" {
+		q.Set("searchType", filters.SearchType)
+	}
+	setIntParam(q, "creationMin", filters.CreationMin, DefaultCreationYearMin)
+	setIntParam(q, "creationMax", filters.CreationMax, DefaultCreationYearMax)
+	setIntParam(q, "albumMin", filters.AlbumMin, DefaultAlbumYearMin)
+	setIntParam(q, "albumMax", filters.AlbumMax, DefaultAlbumYearMax)
+	for _, members := range filters.BandMembers {
+		q.Add("bandMembers", members)
+	}
+	for _, location := range filters.PerformanceLocations {
+		q.Add("locations", location)
+	}
+	return q
+}
+
+// setIntParam sets the parameter unless the value equals its default.
+func setIntParam(q url.Values, key string, val, fallback int) {
+	if val != fallback {
+		q.Set(key, strconv.Itoa(val))
+	}
+}
+
 func parseInt(val string, fallback int) int {
 	if i, err := strconv.Atoi(val); err == nil {
 		return i
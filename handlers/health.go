@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"groopie_local/services"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// startTime is when the server process started, reported by /status.
+var startTime = time.Now()
+
+// BuildInfo identifies the running binary.
+type BuildInfo struct {
+	GoVersion string `json:"goVersion"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	BuiltAt   string `json:"builtAt,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// Status is the body of the /status endpoint.
+type Status struct {
+	// Status is "ok", "degraded" when serving stale data or the API circuit is not closed, or "unavailable" without data.
+	Status    string                 `json:"status"`
+	StartedAt time.Time              `json:"startedAt"`
+	Uptime    float64                `json:"uptimeSeconds"`
+	Data      services.CacheStatus   `json:"data"`
+	Upstream  services.CircuitStatus `json:"upstream"`
+	Build     BuildInfo              `json:"build"`
+}
+
+// HealthzHandler reports that the process is alive and able to serve requests.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintln(w, "ok")
+}
+
+// ReadyzHandler reports whether the server can serve pages: it responds 200 once the data is loaded,
+// and 503 with the reason before the first load or when the data is too old.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if ready, reason := services.Ready(); !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "not ready: "+reason)
+		return
+	}
+	fmt.Fprintln(w, "ready")
+}
+
+// StatusHandler reports the age of the data, the last refresh error, the state of the API circuit breaker
+// and the build information as JSON. It responds 503 when no data is loaded.
+func StatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		Status:    "ok",
+		StartedAt: startTime,
+		Uptime:    time.Since(startTime).Seconds(),
+		Data:      services.GetCacheStatus(),
+		Upstream:  services.APICircuit(),
+		Build:     buildInfo(),
+	}
+	ready, _ := services.Ready()
+	switch {
+	case status.Data.Artists == 0:
+		status.Status = "unavailable"
+	case !ready || status.Data.LastRefreshError != "" || status.Upstream.State != services.CircuitClosed:
+		status.Status = "degraded"
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if status.Status == "unavailable" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, r, status)
+}
+
+// buildInfo reads the Go version, module version and VCS information embedded in the binary.
+func buildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version(), Version: "unknown"}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Version = build.Main.Version
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.BuiltAt = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
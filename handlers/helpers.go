@@ -1,17 +1,32 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"groopie_local/metrics"
 	"groopie_local/models"
-	"html/template"
-	"log"
+	"groopie_local/services"
+	"io/fs"
+	"log/slog"
 	"net/http"
-	"path/filepath"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 )
 
-const templateDir = "templates"
+// templateErrors counts the templates that failed to render, by template and stage (parse or execute).
+var templateErrors = metrics.NewCounter("groupie_template_render_errors_total",
+	"Templates that failed to render, by template and stage (parse or execute).", "template", "stage")
+
+// templateFS holds the HTML templates at its root. It is set by LoadTemplates.
+var templateFS fs.FS = os.DirFS("templates")
+
+// serveTemplateFile sends a static HTML file of the templates, e.g. "welcome.html".
+func serveTemplateFile(w http.ResponseWriter, r *http.Request, name string) {
+	http.ServeFileFS(w, r, templateFS, name)
+}
 
 // TemplateData holds the data passed to templates.
 // It includes information such as the page title, list of artists,
@@ -24,28 +39,107 @@ type TemplateData struct {
 	SearchType  string
 	SortBy      string
 	Message     string
+	Diffs       []services.Diff
+	Webhooks    []services.Webhook
+	Deliveries  []services.WebhookDelivery
+	User        *models.User
+	Form        string
+	Next        string
+	BasePath    string
+	BaseURL     string
+	Query       string
+	Similar     []services.SimilarArtist
+	Events      []services.Event
+	Tour        services.TourStats
+	Stats       services.Stats
+	Place       services.Place
+	Member      models.Member
+	Members     []models.Member
+	Calendar    Calendar
+	Comparison  services.Comparison
+	Selected    []int
+	// Status is the HTTP status code shown by the error page, if any.
+	Status int
+	// CSRFToken is posted back by the forms of logged-in users. writePage sets it from the request.
+	CSRFToken string
+}
+
+// maxPooledBuffer is the capacity above which a render buffer is dropped instead of returned to the pool,
+// so one unusually large page does not keep its memory for good.
+const maxPooledBuffer = 1 << 20
+
+// bufferPool holds the buffers pages are rendered into before being sent.
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// renderTemplate renders a specified HTML template, parsed at startup with the shared partials, with the provided data.
+// If the template is missing, cannot be reloaded or fails to execute, it logs the error and sends the
+// error page with an HTTP 500 Internal Server Error status instead.
+func renderTemplate(w http.ResponseWriter, r *http.Request, name string, data TemplateData) {
+	renderPage(w, r, http.StatusOK, name, data)
 }
 
-// renderTemplate renders a specified HTML template along with an additional filter modal template.
-// It constructs the template file paths, parses them, and executes the template with the provided data.
-// If parsing or execution fails, it logs the error and sends an HTTP 500 Internal Server Error response.
-func renderTemplate(w http.ResponseWriter, name string, data TemplateData) {
-	// Construct full file paths for the main template and the filter modal.
-	tmplPath := filepath.Join(templateDir, name+".html")
-	filterPath := filepath.Join(templateDir, "filterModal.html")
+// renderPage renders a template like renderTemplate, with the given response status.
+// The page is rendered into a buffer first, so nothing is sent unless it rendered completely:
+// a failure halfway through a page results in a clean error page rather than a truncated one.
+func renderPage(w http.ResponseWriter, r *http.Request, status int, name string, data TemplateData) {
+	if err := writePage(w, r, status, name, data); err != nil {
+		renderError(w, r, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+	}
+}
 
-	// Parse the main template file and the filter modal.
-	tmpl, err := template.ParseFiles(tmplPath, filterPath)
+// renderError sends the error page with the given status and message.
+// If the error page itself cannot be rendered, it falls back to a plain text response.
+func renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	clearValidators(w)
+	data := TemplateData{Title: http.StatusText(status) + " - Groupie Tracker", Message: message, Status: status}
+	if err := writePage(w, r, status, "error", data); err != nil {
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// writePage executes a template into a pooled buffer and, if it succeeded, sends it with the given status.
+// Errors are logged and counted; nothing is written to w when an error is returned.
+func writePage(w http.ResponseWriter, r *http.Request, status int, name string, data TemplateData) error {
+	data.CSRFToken = csrfToken(r)
+	tmpl, err := templates.lookup(name)
 	if err != nil {
-		log.Printf("Error parsing template (%s): %v", name, err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		slog.ErrorContext(r.Context(), "Error parsing template", "template", name, "error", err)
+		templateErrors.Inc(name, "parse")
+		return err
 	}
 
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+
 	// Execute the parsed template using the provided data.
-	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing template (%s): %v", name, err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	if err := tmpl.Execute(buf, data); err != nil {
+		slog.ErrorContext(r.Context(), "Error executing template", "template", name, "error", err)
+		templateErrors.Inc(name, "execute")
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+	return nil
+}
+
+// writeJSON encodes v as the JSON response body, as application/json unless the caller already set
+// a more specific JSON type such as application/geo+json.
+// If encoding fails, it logs the error and sends an HTTP 500 Internal Server Error response.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding JSON response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
 
@@ -157,7 +251,7 @@ func FilterByFirstAlbumDateFull(artists []models.ArtistFull, minYear, maxYear in
 	for _, artistFull := range artists {
 		year, err := extractYear(artistFull.Artist.FirstAlbum)
 		if err != nil {
-			log.Printf("Error extracting year from first album date for artist %s: %v", artistFull.Artist.Name, err)
+			slog.Warn("Error extracting year from first album date", "artist", artistFull.Artist.Name, "error", err)
 			continue
 		}
 		if year >= minYear && year <= maxYear {
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// useTemplates loads the given page templates for the test, then restores the previous ones.
+func useTemplates(t *testing.T, pages map[string]string) {
+	t.Helper()
+	previous, previousFS := templates, templateFS
+	t.Cleanup(func() { templates, templateFS = previous, previousFS })
+
+	fsys := fstest.MapFS{}
+	for name, content := range pages {
+		fsys[name+".html"] = &fstest.MapFile{Data: []byte(content)}
+	}
+	if err := LoadTemplates(fsys, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderPage(t *testing.T) {
+	const failed = "<p>500: Something went wrong. Please try again later.</p>"
+	pages := map[string]string{
+		"ok":     `<h1>{{.Title}}</h1>`,
+		"broken": `<h1>{{.Title}}</h1>{{index .Selected 5}}`,
+		"error":  `<p>{{.Status}}: {{.Message}}</p>`,
+	}
+
+	tests := []struct {
+		name        string
+		pages       map[string]string
+		page        string
+		status      int // Requested status.
+		wantStatus  int
+		want        string
+		contentType string
+	}{
+		{name: "rendered", pages: pages, page: "ok", status: http.StatusOK, wantStatus: http.StatusOK, want: "<h1>Title</h1>", contentType: "text/html; charset=utf-8"},
+		{name: "with a status", pages: pages, page: "ok", status: http.StatusNotFound, wantStatus: http.StatusNotFound, want: "<h1>Title</h1>", contentType: "text/html; charset=utf-8"},
+		{name: "execution error", pages: pages, page: "broken", status: http.StatusOK, wantStatus: http.StatusInternalServerError, want: failed, contentType: "text/html; charset=utf-8"},
+		{name: "unknown page", pages: pages, page: "missing", status: http.StatusOK, wantStatus: http.StatusInternalServerError, want: failed, contentType: "text/html; charset=utf-8"},
+		{
+			name:  "broken error page",
+			pages: map[string]string{"broken": pages["broken"], "error": `<p>{{.Message}}</p>{{index .Selected 5}}`},
+			page:  "broken", status: http.StatusOK, wantStatus: http.StatusInternalServerError,
+			want: "Internal Server Error\n", contentType: "text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTemplates(t, tt.pages)
+			w := httptest.NewRecorder()
+			renderPage(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.status, tt.page, TemplateData{Title: "Title"})
+
+			// Nothing of a page that failed halfway reaches the response.
+			if w.Code != tt.wantStatus || w.Body.String() != tt.want {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantStatus, tt.want)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if tt.contentType == "text/html; charset=utf-8" && w.Header().Get("Content-Length") != strconv.Itoa(w.Body.Len()) {
+				t.Errorf("Content-Length = %q for a body of %d bytes", w.Header().Get("Content-Length"), w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestRenderPageReusesBuffers(t *testing.T) {
+	useTemplates(t, map[string]string{"page": `{{.Title}}`})
+
+	// A buffer left dirty by a previous render must not leak into the next page.
+	for _, title := range []string{strings.Repeat("long title ", 100), "short"} {
+		w := httptest.NewRecorder()
+		renderTemplate(w, httptest.NewRequest(http.MethodGet, "/", nil), "page", TemplateData{Title: title})
+		if w.Body.String() != title {
+			t.Errorf("rendered %d bytes, want %q", w.Body.Len(), title)
+		}
+	}
+}
@@ -2,38 +2,34 @@ package handlers
 
 import (
 	"groopie_local/services"
-	"log"
 	"net/http"
 )
 
 // WelcomeHandler serves the welcome page by sending the welcome.html file to the client.
 func WelcomeHandler(w http.ResponseWriter, r *http.Request) {
 	// Serve the welcome page file.
-	http.ServeFile(w, r, "templates/welcome.html")
+	serveTemplateFile(w, r, "welcome.html")
 }
 
-// NotFoundHandler serves a generic error page for undefined routes.
-func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
-	// Serve an error page for any undefined route.
-	http.ServeFile(w, r, "templates/error.html")
+// NotFoundHandler responds with 404 Not Found for undefined routes.
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) error {
+	return notFound("")
 }
 
 // HomeHandler processes requests for the home page, applying various filters on artist data.
 // It retrieves cached data, applies search and filter parameters from the query string, and then renders the home template.
-func HomeHandler(w http.ResponseWriter, r *http.Request) {
+func HomeHandler(w http.ResponseWriter, r *http.Request) error {
 	if r.URL.Path != "/home" {
-		http.NotFound(w, r)
-		return
+		return notFound("")
 	}
 
-	artists, err := services.GetCachedData()
+	artists, err := services.GetCachedData(r.Context())
 	if err != nil {
-		log.Printf("Error fetching cached data: %v", err)
-		renderTemplate(w, "error", TemplateData{
-			Title:   "Error",
-			Message: "Unable to load data. Please try again later.",
-		})
-		return
+		return unavailable(err)
+	}
+
+	if notModified(w, r) {
+		return nil
 	}
 
 	filters := ParseFilters(r)
@@ -45,8 +41,12 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 		Artists:     filteredArtists,
 		SearchQuery: filters.SearchQuery,
 		SearchType:  filters.SearchType,
+		User:        currentUser(r),
+		Next:        r.URL.RequestURI(),
+		BasePath:    "/home",
+		Query:       r.URL.RawQuery,
 	}
 
-	renderTemplate(w, "home", data)
+	renderTemplate(w, r, "home", data)
+	return nil
 }
-
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MemberHandler renders the page of a band member (/member/{slug}), e.g. /member/freddie-mercury,
+// listing every artist the member plays in and their bandmates.
+// Other spellings of the slug, e.g. /member/Freddie_Mercury, are redirected to the canonical one.
+func MemberHandler(w http.ResponseWriter, r *http.Request) error {
+	requested := strings.TrimPrefix(r.URL.Path, "/member/")
+	slug := models.MemberSlug(requested)
+
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	member, found := services.FindMember(artists, slug)
+	if slug == "" || !found {
+		return notFound("Member not found")
+	}
+	if requested != slug {
+		http.Redirect(w, r, "/member/"+url.PathEscape(slug), http.StatusMovedPermanently)
+		return nil
+	}
+	if notModified(w, r) {
+		return nil
+	}
+
+	renderTemplate(w, r, "member", TemplateData{
+		Title:  member.Name + " - Groupie Tracker",
+		Member: member,
+	})
+	return nil
+}
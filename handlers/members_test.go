@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMemberHandler(t *testing.T) {
+	if err := LoadTemplates(os.DirFS("../templates"), false); err != nil {
+		t.Fatal(err)
+	}
+	loadTestData(t)
+
+	tests := []struct {
+		path     string
+		status   int
+		location string // Expected redirect, if any.
+	}{
+		{path: "/member/freddie-mercury", status: http.StatusOK},
+		{path: "/member/Freddie-Mercury", status: http.StatusMovedPermanently, location: "/member/freddie-mercury"},
+		{path: "/member/freddie_mercury", status: http.StatusMovedPermanently, location: "/member/freddie-mercury"},
+		{path: "/member/Freddie%20Mercury", status: http.StatusMovedPermanently, location: "/member/freddie-mercury"},
+		{path: "/member/brian-may", status: http.StatusNotFound},
+		{path: "/member/", status: http.StatusNotFound},
+		{path: "/member/---", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandlerFunc(MemberHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("Location = %q, want %q", location, tt.location)
+			}
+			if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), "Queen") {
+				t.Error("the member page does not list the member's artist")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"strings"
+)
+
+// LocationHandler renders the page of a concert location (/location/{slug}), e.g. /location/london-uk,
+// listing the artists that played there and their concerts in chronological order.
+func LocationHandler(w http.ResponseWriter, r *http.Request) error {
+	return renderPlace(w, r, strings.TrimPrefix(r.URL.Path, "/location/"), services.FindLocation)
+}
+
+// CountryHandler renders the page of a country (/country/{code}), e.g. /country/usa,
+// listing its locations, the artists that played there and their concerts in chronological order.
+func CountryHandler(w http.ResponseWriter, r *http.Request) error {
+	return renderPlace(w, r, strings.TrimPrefix(r.URL.Path, "/country/"), services.FindCountry)
+}
+
+// renderPlace looks up the place with find and renders it, or fails with 404 Not Found if nobody played there.
+func renderPlace(w http.ResponseWriter, r *http.Request, slug string, find func([]models.ArtistFull, string) (services.Place, bool)) error {
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	place, found := find(artists, strings.ToLower(slug))
+	if slug == "" || !found {
+		return notFound("Place not found")
+	}
+	if notModified(w, r) {
+		return nil
+	}
+
+	renderTemplate(w, r, "place", TemplateData{
+		Title: place.Name + " - Groupie Tracker",
+		Place: place,
+	})
+	return nil
+}
@@ -1,25 +1,25 @@
 package handlers
 
 import (
-	"encoding/json"
 	"groopie_local/models"
 	"groopie_local/services"
-	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
 // Suggestion represents a single autocomplete suggestion, including its display value and the original input.
 type Suggestion struct {
-	Value string `json:"value"` // The formatted suggestion string (e.g., "Artist Name - artist").
-	Input string `json:"input"` // The original input string that triggered this suggestion.
+	Value string `json:"value"`         // The formatted suggestion string (e.g., "Artist Name - artist").
+	Input string `json:"input"`         // The original input string that triggered this suggestion.
+	URL   string `json:"url,omitempty"` // The page to open when the suggestion is selected, if any.
 }
 
 // SearchHandler handles search requests for autocomplete functionality.
 // It reads query parameters, fetches cached artist data, generates suggestions based on the search type,
 // and responds with a JSON array of suggestions.
-func SearchHandler(w http.ResponseWriter, r *http.Request) {
+func SearchHandler(w http.ResponseWriter, r *http.Request) error {
 	// Retrieve the search query (parameter "q") and the search type (parameter "searchType").
 	query := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("q")))
 	searchType := r.URL.Query().Get("searchType")
@@ -27,7 +27,7 @@ func SearchHandler(w http.ResponseWriter, r *http.Request) {
 	// If no query is provided, redirect the user to the home page.
 	if query == "" {
 		http.Redirect(w, r, "/home", http.StatusSeeOther) // 303 See Other redirect.
-		return
+		return nil
 	}
 
 	// Default the search type to "general" if not provided.
@@ -39,23 +39,21 @@ func SearchHandler(w http.ResponseWriter, r *http.Request) {
 	query = strings.ToLower(query)
 
 	// Fetch the cached artist data.
-	artistsFull, err := services.GetCachedData()
+	artistsFull, err := services.GetCachedData(r.Context())
 	if err != nil {
-		log.Printf("Error fetching cached data: %v", err)
-		http.Error(w, "Unable to fetch data", http.StatusInternalServerError)
-		return
+		return unavailable(err)
+	}
+
+	if notModified(w, r) {
+		return nil
 	}
 
 	// Generate autocomplete suggestions based on the search query and type.
 	suggestions := generateSuggestions(artistsFull, query, searchType)
 
-	// Set the response content type to JSON.
-	w.Header().Set("Content-Type", "application/json")
 	// Encode the suggestions slice into JSON and write it to the response.
-	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
+	writeJSON(w, r, suggestions)
+	return nil
 }
 
 // generateSuggestions creates a list of autocomplete suggestions based on the provided artist data,
@@ -77,13 +75,13 @@ func generateSuggestions(artistsFull []models.ArtistFull, query, searchType stri
 		case "name":
 			// If the artist's name contains the query, add a suggestion.
 			if strings.Contains(strings.ToLower(artist.Artist.Name), query) {
-				addSuggestion(&suggestions, uniqueSuggestions, artist.Artist.Name, "artist")
+				addSuggestion(&suggestions, uniqueSuggestions, artist.Artist.Name, "artist", "")
 			}
 		case "location":
 			// Iterate over each location in the artist's performance data.
 			for location := range artist.Relations.DatesLocations {
 				if strings.Contains(strings.ToLower(location), query) {
-					addSuggestion(&suggestions, uniqueSuggestions, location, "venue for "+artist.Artist.Name)
+					addSuggestion(&suggestions, uniqueSuggestions, location, "venue for "+artist.Artist.Name, "/location/"+url.PathEscape(location))
 				}
 			}
 		case "date":
@@ -93,7 +91,7 @@ func generateSuggestions(artistsFull []models.ArtistFull, query, searchType stri
 				for _, dates := range artist.Relations.DatesLocations {
 					for _, date := range dates {
 						if strings.Contains(date, query) {
-							addSuggestion(&suggestions, uniqueSuggestions, date, "concert date for "+artist.Artist.Name)
+							addSuggestion(&suggestions, uniqueSuggestions, date, "concert date for "+artist.Artist.Name, "")
 						}
 					}
 				}
@@ -102,25 +100,25 @@ func generateSuggestions(artistsFull []models.ArtistFull, query, searchType stri
 			// General search: check multiple fields.
 			// Match artist name.
 			if strings.Contains(strings.ToLower(artist.Artist.Name), query) {
-				addSuggestion(&suggestions, uniqueSuggestions, artist.Artist.Name, "artist")
+				addSuggestion(&suggestions, uniqueSuggestions, artist.Artist.Name, "artist", "")
 			}
 
 			// Match band members.
 			for _, member := range artist.Artist.Members {
 				if strings.Contains(strings.ToLower(member), query) {
-					addSuggestion(&suggestions, uniqueSuggestions, member, "member of "+artist.Artist.Name)
+					addSuggestion(&suggestions, uniqueSuggestions, member, "member of "+artist.Artist.Name, "/member/"+url.PathEscape(models.MemberSlug(member)))
 				}
 			}
 
 			// Match first album.
 			if strings.Contains(strings.ToLower(artist.Artist.FirstAlbum), query) {
-				addSuggestion(&suggestions, uniqueSuggestions, artist.Artist.FirstAlbum, "first album by "+artist.Artist.Name)
+				addSuggestion(&suggestions, uniqueSuggestions, artist.Artist.FirstAlbum, "first album by "+artist.Artist.Name, "")
 			}
 
 			// Match creation year.
 			creationYear := strconv.Itoa(artist.Artist.CreationDate)
 			if strings.Contains(creationYear, query) {
-				addSuggestion(&suggestions, uniqueSuggestions, creationYear, "creation year of "+artist.Artist.Name)
+				addSuggestion(&suggestions, uniqueSuggestions, creationYear, "creation year of "+artist.Artist.Name, "")
 			}
 
 			// Match concert dates.
@@ -128,7 +126,7 @@ func generateSuggestions(artistsFull []models.ArtistFull, query, searchType stri
 				for _, dates := range artist.Relations.DatesLocations {
 					for _, date := range dates {
 						if strings.Contains(date, query) {
-							addSuggestion(&suggestions, uniqueSuggestions, date, "concert date for "+artist.Artist.Name)
+							addSuggestion(&suggestions, uniqueSuggestions, date, "concert date for "+artist.Artist.Name, "")
 						}
 					}
 				}
@@ -137,7 +135,7 @@ func generateSuggestions(artistsFull []models.ArtistFull, query, searchType stri
 			// Match locations.
 			for location := range artist.Relations.DatesLocations {
 				if strings.Contains(strings.ToLower(location), query) {
-					addSuggestion(&suggestions, uniqueSuggestions, location, "venue for "+artist.Artist.Name)
+					addSuggestion(&suggestions, uniqueSuggestions, location, "venue for "+artist.Artist.Name, "/location/"+url.PathEscape(location))
 				}
 			}
 		}
@@ -148,12 +146,13 @@ func generateSuggestions(artistsFull []models.ArtistFull, query, searchType stri
 
 // addSuggestion constructs a suggestion string by combining the input and a descriptive tag.
 // It ensures the suggestion is unique before adding it to the suggestions slice.
-func addSuggestion(suggestions *[]Suggestion, uniqueSuggestions map[string]bool, input string, tag string) {
+// With a non-empty link, selecting the suggestion opens that page instead of searching for its input.
+func addSuggestion(suggestions *[]Suggestion, uniqueSuggestions map[string]bool, input string, tag string, link string) {
 	// Format the suggestion as "input - tag".
 	suggestion := input + " - " + tag
 	// Add the suggestion only if it hasn't already been added.
 	if !uniqueSuggestions[suggestion] {
-		*suggestions = append(*suggestions, Suggestion{Value: suggestion, Input: input})
+		*suggestions = append(*suggestions, Suggestion{Value: suggestion, Input: input, URL: link})
 		uniqueSuggestions[suggestion] = true
 	}
 }
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateSuggestions(t *testing.T) {
+	artists := []models.ArtistFull{
+		{
+			Artist: models.Artist{ID: 1, Name: "Queen", Members: []string{"Freddie Mercury", "Brian May"}, CreationDate: 1970, FirstAlbum: "13-07-1973"},
+			Relations: models.Relations{DatesLocations: map[string][]string{
+				"saint_étienne-france": {"02-02-1977"},
+			}},
+		},
+		{
+			Artist: models.Artist{ID: 2, Name: "Queens of the Stone Age", Members: []string{"Josh Homme"}, CreationDate: 1996, FirstAlbum: "06-10-1998"},
+			Relations: models.Relations{DatesLocations: map[string][]string{
+				"queensland-australia": {"01-01-1999"},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		searchType string
+		want       []Suggestion
+	}{
+		{
+			name: "name", query: "queen", searchType: "name",
+			want: []Suggestion{
+				{Value: "Queen - artist", Input: "Queen"},
+				{Value: "Queens of the Stone Age - artist", Input: "Queens of the Stone Age"},
+			},
+		},
+		{
+			name: "location links to the escaped location page", query: "étienne", searchType: "location",
+			want: []Suggestion{
+				{Value: "saint_étienne-france - venue for Queen", Input: "saint_étienne-france", URL: "/location/saint_%C3%A9tienne-france"},
+			},
+		},
+		{
+			name: "date", query: "1977", searchType: "date",
+			want: []Suggestion{{Value: "02-02-1977 - concert date for Queen", Input: "02-02-1977"}},
+		},
+		{
+			name: "date needs a number", query: "feb", searchType: "date",
+		},
+		{
+			name: "general links members", query: "may", searchType: "general",
+			want: []Suggestion{{Value: "Brian May - member of Queen", Input: "Brian May", URL: "/member/brian-may"}},
+		},
+		{
+			name: "general", query: "queens", searchType: "general",
+			want: []Suggestion{
+				{Value: "Queens of the Stone Age - artist", Input: "Queens of the Stone Age"},
+				{Value: "queensland-australia - venue for Queens of the Stone Age", Input: "queensland-australia", URL: "/location/queensland-australia"},
+			},
+		},
+		{
+			name: "general years and albums", query: "197", searchType: "general",
+			want: []Suggestion{
+				{Value: "13-07-1973 - first album by Queen", Input: "13-07-1973"},
+				{Value: "1970 - creation year of Queen", Input: "1970"},
+				{Value: "02-02-1977 - concert date for Queen", Input: "02-02-1977"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateSuggestions(artists, tt.query, tt.searchType)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generateSuggestions(%q, %q) = %+v, want %+v", tt.query, tt.searchType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSuggestionDeduplicates(t *testing.T) {
+	var suggestions []Suggestion
+	unique := make(map[string]bool)
+	addSuggestion(&suggestions, unique, "london-uk", "venue for Queen", "/location/london-uk")
+	addSuggestion(&suggestions, unique, "london-uk", "venue for Queen", "/location/london-uk")
+	addSuggestion(&suggestions, unique, "london-uk", "venue for ABBA", "/location/london-uk")
+
+	if len(suggestions) != 2 {
+		t.Errorf("got %d suggestions, want 2: %+v", len(suggestions), suggestions)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"groopie_local/models"
+	"groopie_local/services"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SavedSearchesHandler lists the user's saved searches and notifications (GET /searches)
+// and saves a new search from the submitted query string (POST /searches).
+func SavedSearchesHandler(w http.ResponseWriter, r *http.Request) error {
+	user := currentUser(r)
+	if user == nil {
+		http.Redirect(w, r, "/login?next=/searches", http.StatusSeeOther)
+		return nil
+	}
+
+	if r.Method != http.MethodPost {
+		renderTemplate(w, r, "searches", TemplateData{
+			Title:   "Saved Searches - Groupie Tracker",
+			User:    user,
+			BaseURL: baseURL(r),
+			Message: r.URL.Query().Get("message"),
+		})
+		return nil
+	}
+	if err := checkCSRF(r); err != nil {
+		return err
+	}
+
+	query, err := url.ParseQuery(r.FormValue("query"))
+	if err != nil {
+		return badRequest("Invalid search query")
+	}
+	filters := ParseFiltersQuery(query)
+
+	artists, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	_, err = services.SaveSearch(user.Username, r.FormValue("name"), filters, r.FormValue("notify") != "", artistIDs(FilterArtists(artists, filters)))
+	if err != nil {
+		return redirectWithMessage(w, r, err)
+	}
+	http.Redirect(w, r, "/searches", http.StatusSeeOther)
+	return nil
+}
+
+// SavedSearchHandler updates one of the user's saved searches:
+// POST /searches/{code}/rename, /searches/{code}/notify or /searches/{code}/delete.
+func SavedSearchHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return methodNotAllowed()
+	}
+	user := currentUser(r)
+	if user == nil {
+		http.Redirect(w, r, "/login?next=/searches", http.StatusSeeOther)
+		return nil
+	}
+	if err := checkCSRF(r); err != nil {
+		return err
+	}
+
+	code, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/searches/"), "/")
+
+	var err error
+	switch action {
+	case "rename":
+		err = services.RenameSearch(user.Username, code, r.FormValue("name"))
+	case "notify":
+		err = services.SetSearchNotify(user.Username, code, r.FormValue("notify") != "")
+	case "delete":
+		err = services.DeleteSearch(user.Username, code)
+	default:
+		return notFound("")
+	}
+	if err != nil {
+		return redirectWithMessage(w, r, err)
+	}
+	http.Redirect(w, r, "/searches", http.StatusSeeOther)
+	return nil
+}
+
+// ClearNotificationsHandler removes the user's notifications (POST /notifications/clear).
+func ClearNotificationsHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return methodNotAllowed()
+	}
+	user := currentUser(r)
+	if user == nil {
+		http.Redirect(w, r, "/login?next=/searches", http.StatusSeeOther)
+		return nil
+	}
+	if err := checkCSRF(r); err != nil {
+		return err
+	}
+	if err := services.ClearNotifications(user.Username); err != nil {
+		return internalError("Unable to clear notifications. Please try again later.", err)
+	}
+	http.Redirect(w, r, "/searches", http.StatusSeeOther)
+	return nil
+}
+
+// ShortLinkHandler opens a shared saved search (GET /s/{code}) by redirecting to the home page with its filters.
+func ShortLinkHandler(w http.ResponseWriter, r *http.Request) error {
+	code := strings.TrimPrefix(r.URL.Path, "/s/")
+	search, err := services.FindSharedSearch(code)
+	switch {
+	case errors.Is(err, services.ErrSearchNotFound):
+		return notFound("Search not found")
+	case err != nil:
+		return internalError("Unable to open the search. Please try again later.", err)
+	}
+	http.Redirect(w, r, "/home?"+FiltersQuery(search.Filters).Encode(), http.StatusSeeOther)
+	return nil
+}
+
+// CheckSavedSearches re-runs the saved searches after a refresh, records the artists they now match
+// and, for the searches with notifications enabled, notifies their owners when these changed.
+// The searches run against the data of the refresh that produced the diff, so a later refresh
+// cannot make them record results the diff does not account for. It is registered with services.OnChange.
+func CheckSavedSearches(diff services.Diff) {
+	if diff.IsEmpty() {
+		return
+	}
+	logger := slog.With("listener", "saved searches")
+	all, err := services.ListUsers()
+	if err != nil {
+		if !errors.Is(err, services.ErrAccountsDisabled) {
+			logger.Error("Error listing users", "error", err)
+		}
+		return
+	}
+
+	for _, user := range all {
+		for _, search := range user.SavedSearches {
+			ids := artistIDs(FilterArtists(diff.Artists, search.Filters))
+			added, removed := diffIDs(search.ResultIDs, ids)
+			if added == 0 && removed == 0 {
+				continue
+			}
+
+			// The results are kept current even without notifications, so turning them on later
+			// only reports the changes made from then on.
+			var message string
+			if search.Notify {
+				message = fmt.Sprintf("%q now matches %d artist(s): %d new, %d no longer matching.", search.Name, len(ids), added, removed)
+			}
+			if err := services.RecordSearchResults(user.Username, search.Code, ids, message); err != nil {
+				logger.Error("Error recording results of saved search", "user", user.Username, "search", search.Code, "error", err)
+			}
+		}
+	}
+}
+
+// redirectWithMessage sends the user back to the saved searches page with the error shown,
+// or returns a 500 Internal Server Error for unexpected errors.
+func redirectWithMessage(w http.ResponseWriter, r *http.Request, err error) error {
+	switch {
+	case errors.Is(err, services.ErrSearchNotFound), errors.Is(err, services.ErrInvalidSearchName), errors.Is(err, services.ErrTooManySearches):
+		http.Redirect(w, r, "/searches?message="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return nil
+	default:
+		return internalError("Unable to update your saved searches. Please try again later.", err)
+	}
+}
+
+// artistIDs returns the IDs of the artists in ascending order.
+func artistIDs(artists []models.ArtistFull) []int {
+	ids := make([]int, 0, len(artists))
+	for _, artist := range artists {
+		ids = append(ids, artist.Artist.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// diffIDs counts the IDs of b missing from a (added) and the IDs of a missing from b (removed).
+func diffIDs(a, b []int) (added, removed int) {
+	inA := make(map[int]bool, len(a))
+	for _, id := range a {
+		inA[id] = true
+	}
+	inB := make(map[int]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+		if !inA[id] {
+			added++
+		}
+	}
+	for _, id := range a {
+		if !inB[id] {
+			removed++
+		}
+	}
+	return added, removed
+}
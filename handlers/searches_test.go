@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"groopie_local/models"
+	"groopie_local/services"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckSavedSearches(t *testing.T) {
+	store, err := services.NewFileUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	services.ConfigureUserStore(store)
+	defer services.ConfigureUserStore(nil)
+	if _, err := services.Register("alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	seventies := models.Filters{CreationMin: 1972, CreationMax: 1979, AlbumMin: 1900, AlbumMax: 2100}
+	notified, err := services.SaveSearch("alice", "Seventies", seventies, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quiet, err := services.SaveSearch("alice", "Seventies, quietly", seventies, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queen := models.ArtistFull{Artist: models.Artist{ID: 1, Name: "Queen", CreationDate: 1970, FirstAlbum: "13-07-1973"}}
+	acdc := models.ArtistFull{Artist: models.Artist{ID: 2, Name: "AC/DC", CreationDate: 1973, FirstAlbum: "17-02-1975"}}
+	// The searches run against the data carried by the diff; the cache is never loaded in this test.
+	diff := services.DiffData([]models.ArtistFull{queen}, []models.ArtistFull{queen, acdc})
+	diff.Artists = []models.ArtistFull{queen, acdc}
+	CheckSavedSearches(diff)
+
+	user, err := services.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, search := range user.SavedSearches {
+		if !slices.Equal(search.ResultIDs, []int{2}) {
+			t.Errorf("%s: results %v, want [2]", search.Name, search.ResultIDs)
+		}
+	}
+	if len(user.Notifications) != 1 || user.Notifications[0].SearchCode != notified.Code {
+		t.Errorf("notifications = %+v, want one for %s and none for %s", user.Notifications, notified.Code, quiet.Code)
+	}
+
+	// Results that did not change since the last check notify nobody.
+	CheckSavedSearches(diff)
+	if user, _ := services.GetUser("alice"); len(user.Notifications) != 1 {
+		t.Errorf("%d notifications after an unchanged check, want 1", len(user.Notifications))
+	}
+}
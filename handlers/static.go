@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// staticPrefix is the path the static files are served under.
+const staticPrefix = "/static/"
+
+// staticAssets serves the static files linked by the asset template function. It is set by StaticHandler.
+var staticAssets *assetServer
+
+// assetServer serves static files with caching headers derived from a hash of their content.
+type assetServer struct {
+	fsys   fs.FS
+	reload bool
+	files  http.Handler
+	// hashes maps the path of each file, e.g. "css/styles.css", to the hash of its content. It is unused when reloading.
+	hashes map[string]string
+}
+
+// StaticHandler returns the handler serving the files of fsys under /static/.
+// Every file gets an ETag computed from its content, and templates link to them with the asset function,
+// which adds the same hash to the URL: as such fingerprinted URLs change along with the file, browsers cache them
+// for a year, while plain URLs are revalidated on each use. The hashes are computed once, or on every request
+// with reload, for development, in which case nothing is cached for long.
+// It must be called before the server starts.
+func StaticHandler(fsys fs.FS, reload bool) (http.Handler, error) {
+	server := &assetServer{
+		fsys:   fsys,
+		reload: reload,
+		files:  http.StripPrefix(staticPrefix, http.FileServerFS(fsys)),
+		hashes: map[string]string{},
+	}
+	if !reload {
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			server.hashes[name], err = hashFile(fsys, name)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	staticAssets = server
+	return server, nil
+}
+
+// hash returns the hash of the content of a static file, or "" if there is no such file.
+func (s *assetServer) hash(name string) string {
+	if !s.reload {
+		return s.hashes[name]
+	}
+	hash, err := hashFile(s.fsys, name)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+func (s *assetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The file server answers If-None-Match with 304 Not Modified when the ETag is set beforehand.
+	if hash := s.hash(strings.TrimPrefix(r.URL.Path, staticPrefix)); hash != "" {
+		w.Header().Set("ETag", `"`+hash+`"`)
+		if !s.reload && r.URL.Query().Get("v") == hash {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+	}
+	s.files.ServeHTTP(w, r)
+}
+
+// assetURL returns the URL of a static file, e.g. "/static/css/styles.css?v=1a2b3c4d5e6f7a8b" for "css/styles.css",
+// fingerprinted with the hash of its content when the file exists. It is the asset template function.
+func assetURL(name string) string {
+	url := staticPrefix + name
+	if staticAssets != nil {
+		if hash := staticAssets.hash(name); hash != "" {
+			url += "?v=" + hash
+		}
+	}
+	return url
+}
+
+// hashFile returns the first 16 hexadecimal digits of the SHA-256 hash of a file, enough to tell its versions apart.
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticHandler(t *testing.T) {
+	previous := staticAssets
+	t.Cleanup(func() { staticAssets = previous })
+
+	fsys := fstest.MapFS{"css/styles.css": &fstest.MapFile{Data: []byte("body { color: black; }")}}
+	hash, err := hashFile(fsys, "css/styles.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const immutable, revalidate = "public, max-age=31536000, immutable", "no-cache"
+
+	tests := []struct {
+		name        string
+		reload      bool
+		path        string
+		ifNoneMatch string
+		status      int
+		etag        string
+		cache       string
+	}{
+		{name: "fingerprinted", path: "/static/css/styles.css?v=" + hash, status: http.StatusOK, etag: `"` + hash + `"`, cache: immutable},
+		{name: "plain", path: "/static/css/styles.css", status: http.StatusOK, etag: `"` + hash + `"`, cache: revalidate},
+		{name: "outdated fingerprint", path: "/static/css/styles.css?v=0123456789abcdef", status: http.StatusOK, etag: `"` + hash + `"`, cache: revalidate},
+		{name: "revalidated", path: "/static/css/styles.css", ifNoneMatch: `"` + hash + `"`, status: http.StatusNotModified, etag: `"` + hash + `"`, cache: revalidate},
+		{name: "missing", path: "/static/css/missing.css", status: http.StatusNotFound},
+		{name: "reload", reload: true, path: "/static/css/styles.css?v=" + hash, status: http.StatusOK, etag: `"` + hash + `"`, cache: revalidate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := StaticHandler(fsys, tt.reload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if etag := w.Header().Get("ETag"); etag != tt.etag {
+				t.Errorf("ETag = %q, want %q", etag, tt.etag)
+			}
+			if cache := w.Header().Get("Cache-Control"); cache != tt.cache {
+				t.Errorf("Cache-Control = %q, want %q", cache, tt.cache)
+			}
+		})
+	}
+}
+
+func TestAssetURL(t *testing.T) {
+	previous := staticAssets
+	t.Cleanup(func() { staticAssets = previous })
+
+	fsys := fstest.MapFS{"js/app.js": &fstest.MapFile{Data: []byte("console.log(1)")}}
+	hash, err := hashFile(fsys, "js/app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hash) != 16 {
+		t.Errorf("hashFile() = %q, want 16 hexadecimal digits", hash)
+	}
+
+	staticAssets = nil
+	if got := assetURL("js/app.js"); got != "/static/js/app.js" {
+		t.Errorf("assetURL() without static files = %q", got)
+	}
+	for _, reload := range []bool{false, true} {
+		if _, err := StaticHandler(fsys, reload); err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct{ name, want string }{
+			{"js/app.js", "/static/js/app.js?v=" + hash},
+			{"js/missing.js", "/static/js/missing.js"},
+		}
+		for _, tt := range tests {
+			if got := assetURL(tt.name); got != tt.want {
+				t.Errorf("reload %v: assetURL(%q) = %q, want %q", reload, tt.name, got, tt.want)
+			}
+		}
+	}
+
+	// With reload, the fingerprint follows the file as it changes.
+	fsys["js/app.js"] = &fstest.MapFile{Data: []byte("console.log(2)")}
+	if got := assetURL("js/app.js"); got == "/static/js/app.js?v="+hash {
+		t.Errorf("assetURL() = %q after the file changed", got)
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"groopie_local/services"
+	"net/http"
+)
+
+// StatsHandler renders the dashboard of statistics computed over the whole dataset.
+func StatsHandler(w http.ResponseWriter, r *http.Request) error {
+	stats, err := services.GetStats(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+	if notModified(w, r) {
+		return nil
+	}
+
+	renderTemplate(w, r, "stats", TemplateData{
+		Title: "Statistics - Groupie Tracker",
+		Stats: stats,
+	})
+	return nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"groopie_local/services"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// partialsDir holds the templates shared by every page: layouts and partials that only define named templates.
+const partialsDir = "partials"
+
+// templateRegistry holds the page templates, each parsed together with the partials.
+type templateRegistry struct {
+	mu     sync.RWMutex
+	fsys   fs.FS
+	reload bool
+	pages  map[string]*template.Template
+	// modTime is the latest modification time of the parsed files, to detect changes when reloading.
+	modTime time.Time
+}
+
+// templateFuncs are the functions available to every template.
+var templateFuncs = template.FuncMap{
+	// asset returns the fingerprinted URL of a static file, e.g. {{ asset "css/styles.css" }}.
+	"asset": assetURL,
+	// countryName returns the readable name of a country code, e.g. {{ countryName "new_zealand" }}.
+	"countryName": services.CountryName,
+}
+
+// templates is the registry used by renderTemplate. It is set by LoadTemplates.
+var templates = &templateRegistry{pages: map[string]*template.Template{}}
+
+// LoadTemplates parses every page template of fsys (the .html files at its root) with the partials
+// of its partials directory, and checks that they can be executed, so template errors are reported at startup.
+// With reload, the templates are parsed again whenever one of their files changes, for development;
+// this requires a file system reporting modification times, such as os.DirFS.
+// It must be called before the server starts.
+func LoadTemplates(fsys fs.FS, reload bool) error {
+	registry := &templateRegistry{fsys: fsys, reload: reload}
+	if err := registry.parse(); err != nil {
+		return err
+	}
+	templateFS = fsys
+	templates = registry
+	return nil
+}
+
+// parse parses and validates every template, then replaces the pages of the registry.
+// The caller must hold r.mu for writing, or own the registry.
+func (r *templateRegistry) parse() error {
+	modTime, err := latestModTime(r.fsys)
+	if err != nil {
+		return err
+	}
+
+	partials, err := fs.Glob(r.fsys, path.Join(partialsDir, "*.html"))
+	if err != nil {
+		return err
+	}
+	base := template.New("").Funcs(templateFuncs)
+	if len(partials) > 0 {
+		if base, err = base.ParseFS(r.fsys, partials...); err != nil {
+			return fmt.Errorf("parse partials: %w", err)
+		}
+	}
+
+	files, err := fs.Glob(r.fsys, "*.html")
+	if err != nil {
+		return err
+	}
+	pages := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		page, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		if page, err = page.ParseFS(r.fsys, file); err != nil {
+			return fmt.Errorf("parse %s: %w", file, err)
+		}
+		pages[strings.TrimSuffix(file, ".html")] = page.Lookup(file)
+	}
+
+	// Executing the pages runs the contextual escaping of html/template, whose errors would otherwise
+	// only show up at request time. Errors caused by the empty data are expected and ignored.
+	for name, page := range pages {
+		var escapeErr *template.Error
+		if err := page.Execute(io.Discard, TemplateData{}); errors.As(err, &escapeErr) {
+			return fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+
+	r.pages = pages
+	r.modTime = modTime
+	return nil
+}
+
+// lookup returns the page template with the given name, e.g. "home", parsing the templates again first
+// if reloading is enabled and a file changed.
+func (r *templateRegistry) lookup(name string) (*template.Template, error) {
+	if r.reload {
+		if err := r.reloadIfChanged(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	page, ok := r.pages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+	return page, nil
+}
+
+// version returns the latest modification time of the templates, checking the files again when reloading.
+// It is zero for embedded templates.
+func (r *templateRegistry) version() time.Time {
+	if r.reload {
+		if modTime, err := latestModTime(r.fsys); err == nil {
+			return modTime
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.modTime
+}
+
+// reloadIfChanged parses the templates again when a file was modified since they were parsed.
+func (r *templateRegistry) reloadIfChanged() error {
+	modTime, err := latestModTime(r.fsys)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := modTime.After(r.modTime)
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !modTime.After(r.modTime) {
+		return nil // Another request already reloaded them.
+	}
+	if err := r.parse(); err != nil {
+		return err
+	}
+	slog.Info("Reloaded templates")
+	return nil
+}
+
+// latestModTime returns the latest modification time of the .html files of fsys.
+func latestModTime(fsys fs.FS) (time.Time, error) {
+	var latest time.Time
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".html" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParseTemplates(t *testing.T) {
+	partials := map[string]string{"partials/layout.html": `{{define "layout"}}<title>{{.Title}}</title>{{end}}`}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string // Expected in the error, "" for none.
+	}{
+		{
+			name:  "pages with partials",
+			files: map[string]string{"home.html": `{{template "layout" .}}<h1>Home</h1>`, "about.html": `{{template "layout" .}}`},
+		},
+		{
+			name:  "execution errors are left for request time",
+			files: map[string]string{"home.html": `{{index .Selected 5}}`},
+		},
+		{
+			name:  "page syntax",
+			files: map[string]string{"home.html": `{{.Title`},
+			err:   "parse home.html",
+		},
+		{
+			name:  "partial syntax",
+			files: map[string]string{"partials/broken.html": `{{define "broken"}}{{end`},
+			err:   "parse partials",
+		},
+		{
+			name:  "unknown partial",
+			files: map[string]string{"home.html": `{{template "missing" .}}`},
+			err:   "template home",
+		},
+		{
+			name:  "ambiguous escaping context",
+			files: map[string]string{"home.html": `<p {{if .Title}}title="{{end}}>`},
+			err:   "template home",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for name, content := range partials {
+				fsys[name] = &fstest.MapFile{Data: []byte(content)}
+			}
+			for name, content := range tt.files {
+				fsys[name] = &fstest.MapFile{Data: []byte(content)}
+			}
+
+			registry := &templateRegistry{fsys: fsys}
+			err := registry.parse()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("parse() = %v, want an error about %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse() = %v", err)
+			}
+			for name := range tt.files {
+				if _, err := registry.lookup(strings.TrimSuffix(name, ".html")); err != nil {
+					t.Errorf("lookup() = %v", err)
+				}
+			}
+			if _, err := registry.lookup("layout"); err == nil {
+				t.Error("partials are looked up as pages")
+			}
+		})
+	}
+}
+
+func TestTemplatesReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "home.html")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(page, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(page, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	render := func(registry *templateRegistry) string {
+		t.Helper()
+		tmpl, err := registry.lookup("home")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, TemplateData{}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	start := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		reload bool
+		want   string // Rendered after the file changed.
+	}{
+		{reload: false, want: "before"},
+		{reload: true, want: "after"},
+	}
+	for _, tt := range tests {
+		write("before", start)
+		registry := &templateRegistry{fsys: os.DirFS(dir), reload: tt.reload}
+		if err := registry.parse(); err != nil {
+			t.Fatal(err)
+		}
+		version := registry.version()
+
+		write("after", start.Add(time.Minute))
+		if got := render(registry); got != tt.want {
+			t.Errorf("reload %v: rendered %q, want %q", tt.reload, got, tt.want)
+		}
+		if changed := !registry.version().Equal(version); changed != tt.reload {
+			t.Errorf("reload %v: version changed = %v", tt.reload, changed)
+		}
+	}
+
+	// A broken change is reported rather than served.
+	registry := &templateRegistry{fsys: os.DirFS(dir), reload: true}
+	if err := registry.parse(); err != nil {
+		t.Fatal(err)
+	}
+	write("{{.Title", start.Add(2*time.Minute))
+	if _, err := registry.lookup("home"); err == nil {
+		t.Error("lookup() of a broken template succeeded")
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"groopie_local/models"
+	"groopie_local/services"
+	"net/http"
+	"strings"
+)
+
+// geoJSONFeatureCollection is the root object of a GeoJSON document.
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is a single GeoJSON feature with its geometry and properties.
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// geoJSONGeometry holds either a Point ([lon, lat]) or a LineString ([[lon, lat], ...]).
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// kmlRoot is the root element of a KML document.
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+// kmlDocument groups the placemarks of the exported tours.
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+// kmlPlacemark is either a concert location (Point) or a tour path (LineString).
+type kmlPlacemark struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	Point       *kmlGeometry   `xml:"Point,omitempty"`
+	LineString  *kmlGeometry   `xml:"LineString,omitempty"`
+	Data        []kmlDataValue `xml:"ExtendedData>Data,omitempty"`
+}
+
+// kmlGeometry holds KML coordinates as "lon,lat" tuples separated by spaces.
+type kmlGeometry struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlDataValue is a named value attached to a placemark.
+type kmlDataValue struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// tourStop is a geocoded concert location together with the dates played there.
+type tourStop struct {
+	Location string
+	Coords   models.Coordinates
+	Dates    []string
+}
+
+// artistTour is the geocoded tour of a single artist: its stops and the chronological path.
+type artistTour struct {
+	Artist models.Artist
+	Stops  []tourStop
+	Path   []models.Coordinates
+}
+
+// ToursHandler exports the tours of every artist matching the filters in the query string.
+// The format is selected by the path: /tours.geojson or /tours.kml.
+func ToursHandler(w http.ResponseWriter, r *http.Request) error {
+	artistsFull, err := services.GetCachedData(r.Context())
+	if err != nil {
+		return unavailable(err)
+	}
+
+	filtered := FilterArtists(artistsFull, ParseFilters(r))
+
+	switch r.URL.Path {
+	case "/tours.geojson":
+		writeTourGeoJSON(w, r, filtered)
+	case "/tours.kml":
+		writeTourKML(w, r, "Groupie Tracker tours", filtered)
+	default:
+		return notFound("")
+	}
+	return nil
+}
+
+// buildTours places the concert locations of each artist on the map and orders its tour path by date.
+// Only the coordinates already resolved by the background geocoder are used, so a request never waits
+// for Nominatim: the other locations are left out until they are resolved.
+func buildTours(artists []models.ArtistFull) []artistTour {
+	var tours []artistTour
+	for _, artist := range artists {
+		tour := artistTour{Artist: artist.Artist}
+		// stopIndex maps a location to its position in tour.Stops.
+		stopIndex := make(map[string]int)
+
+		for _, concert := range artist.Relations.Concerts() {
+			i, ok := stopIndex[concert.Location]
+			if !ok {
+				c, found := services.CachedCoordinates(concert.Location)
+				if !found {
+					continue
+				}
+				i = len(tour.Stops)
+				stopIndex[concert.Location] = i
+				tour.Stops = append(tour.Stops, tourStop{Location: concert.Location, Coords: c})
+			}
+			tour.Stops[i].Dates = append(tour.Stops[i].Dates, concert.Date.Format(models.ConcertDateLayout))
+			tour.Path = append(tour.Path, tour.Stops[i].Coords)
+		}
+		tours = append(tours, tour)
+	}
+	return tours
+}
+
+// writeTourGeoJSON writes the tours as a GeoJSON FeatureCollection: one Point per location
+// and one LineString per artist following the concerts in chronological order.
+func writeTourGeoJSON(w http.ResponseWriter, r *http.Request, artists []models.ArtistFull) {
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	for _, tour := range buildTours(artists) {
+		for _, stop := range tour.Stops {
+			collection.Features = append(collection.Features, geoJSONFeature{
+				Type: "Feature",
+				Geometry: geoJSONGeometry{
+					Type:        "Point",
+					Coordinates: []float64{stop.Coords.Lon, stop.Coords.Lat},
+				},
+				Properties: map[string]interface{}{
+					"artistId":  tour.Artist.ID,
+					"artist":    tour.Artist.Name,
+					"location":  stop.Location,
+					"dates":     stop.Dates,
+					"firstDate": stop.Dates[0],
+					"lastDate":  stop.Dates[len(stop.Dates)-1],
+				},
+			})
+		}
+
+		// A path needs at least two points to be a valid LineString.
+		if len(tour.Path) < 2 {
+			continue
+		}
+		line := make([][]float64, 0, len(tour.Path))
+		for _, c := range tour.Path {
+			line = append(line, []float64{c.Lon, c.Lat})
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]interface{}{
+				"artistId": tour.Artist.ID,
+				"artist":   tour.Artist.Name,
+				"name":     tour.Artist.Name + " tour",
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	writeJSON(w, r, collection)
+}
+
+// writeTourKML writes the tours as a KML document with the same structure as the GeoJSON export.
+func writeTourKML(w http.ResponseWriter, r *http.Request, name string, artists []models.ArtistFull) {
+	doc := kmlRoot{
+		Xmlns:    "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{Name: name},
+	}
+
+	for _, tour := range buildTours(artists) {
+		for _, stop := range tour.Stops {
+			doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+				Name:        stop.Location,
+				Description: tour.Artist.Name + ": " + strings.Join(stop.Dates, ", "),
+				Point:       &kmlGeometry{Coordinates: kmlCoordinates([]models.Coordinates{stop.Coords})},
+				Data: []kmlDataValue{
+					{Name: "artist", Value: tour.Artist.Name},
+					{Name: "dates", Value: strings.Join(stop.Dates, ",")},
+				},
+			})
+		}
+
+		if len(tour.Path) < 2 {
+			continue
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:       tour.Artist.Name + " tour",
+			LineString: &kmlGeometry{Coordinates: kmlCoordinates(tour.Path)},
+		})
+	}
+
+	writeXML(w, r, "application/vnd.google-earth.kml+xml", doc)
+}
+
+// kmlCoordinates formats coordinates as KML "lon,lat" tuples.
+func kmlCoordinates(coords []models.Coordinates) string {
+	parts := make([]string, 0, len(coords))
+	for _, c := range coords {
+		parts = append(parts, fmt.Sprintf("%f,%f", c.Lon, c.Lat))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"groopie_local/internal/testutil"
+	"groopie_local/models"
+	"groopie_local/services"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// loadTourData loads two artists touring london, paris and an unknown place, and geocodes london and paris
+// through a fake Nominatim so their coordinates are cached.
+func loadTourData(t *testing.T) {
+	t.Helper()
+	api := loadTestData(t)
+	responses := maps.Clone(testutil.APIResponses)
+	responses["artists"] = `[{"id":1,"name":"Queen","members":["Freddie Mercury"],"creationDate":1970,"firstAlbum":"13-07-1973"},` +
+		`{"id":2,"name":"AC/DC","members":["Angus Young"],"creationDate":1973,"firstAlbum":"17-02-1975"}]`
+	responses["relation"] = `{"index":[` +
+		`{"id":1,"datesLocations":{"london-uk":["01-01-2020","03-01-2020"],"paris-france":["02-01-2020"],"atlantis-nowhere":["04-01-2020"]}},` +
+		`{"id":2,"datesLocations":{"paris-france":["05-01-2020"]}}]}`
+	api.SetResponses(responses)
+	if err := services.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	results := map[string]string{
+		"london, uk":    `[{"lat":"51.5","lon":"-0.1"}]`,
+		"paris, france": `[{"lat":"48.9","lon":"2.4"}]`,
+	}
+	nominatim := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(results[r.URL.Query().Get("q")]))
+	}))
+	defer nominatim.Close()
+	services.ConfigureGeocoding(nominatim.URL, 0, 5*time.Second)
+	for _, location := range []string{"london-uk", "paris-france"} {
+		if _, err := services.Geocode(context.Background(), location); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuildTours(t *testing.T) {
+	loadTourData(t)
+	artists, err := services.GetCachedData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tours := buildTours(artists)
+	if len(tours) != 2 {
+		t.Fatalf("got %d tours, want 2", len(tours))
+	}
+	queen := tours[0]
+	var stops []string
+	for _, stop := range queen.Stops {
+		stops = append(stops, stop.Location+" "+stop.Dates[0]+" "+stop.Dates[len(stop.Dates)-1])
+	}
+	// The unknown place is left out, and london is visited twice on the path.
+	if want := []string{"london-uk 01-01-2020 03-01-2020", "paris-france 02-01-2020 02-01-2020"}; !slices.Equal(stops, want) {
+		t.Errorf("Queen's stops = %v, want %v", stops, want)
+	}
+	london, paris := models.Coordinates{Lat: 51.5, Lon: -0.1}, models.Coordinates{Lat: 48.9, Lon: 2.4}
+	if want := []models.Coordinates{london, paris, london}; !slices.Equal(queen.Path, want) {
+		t.Errorf("Queen's path = %v, want %v", queen.Path, want)
+	}
+	if acdc := tours[1]; len(acdc.Stops) != 1 || len(acdc.Path) != 1 {
+		t.Errorf("AC/DC's tour = %+v, want a single stop", acdc)
+	}
+}
+
+func TestToursHandler(t *testing.T) {
+	loadTourData(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		points      int
+		lines       []string // Names of the tour paths.
+	}{
+		{name: "geojson", path: "/tours.geojson", status: http.StatusOK, contentType: "application/geo+json", points: 3, lines: []string{"Queen tour"}},
+		{name: "kml", path: "/tours.kml", status: http.StatusOK, contentType: "application/vnd.google-earth.kml+xml; charset=utf-8", points: 3, lines: []string{"Queen tour"}},
+		{name: "filtered", path: "/tours.geojson?creationMin=1972", status: http.StatusOK, contentType: "application/geo+json", points: 1},
+		{name: "unknown format", path: "/tours.gpx", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandlerFunc(ToursHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+
+			points, lines := 0, []string(nil)
+			if tt.name == "kml" {
+				var doc kmlRoot
+				if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+					t.Fatal(err)
+				}
+				for _, placemark := range doc.Document.Placemarks {
+					if placemark.Point != nil {
+						points++
+					} else {
+						lines = append(lines, placemark.Name)
+					}
+				}
+			} else {
+				var collection struct {
+					Features []struct {
+						Geometry   struct{ Type string }
+						Properties map[string]any
+					}
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+					t.Fatal(err)
+				}
+				for _, feature := range collection.Features {
+					if feature.Geometry.Type == "Point" {
+						points++
+					} else {
+						lines = append(lines, feature.Properties["name"].(string))
+					}
+				}
+			}
+			if points != tt.points || !slices.Equal(lines, tt.lines) {
+				t.Errorf("got %d points and paths %v, want %d and %v", points, lines, tt.points, tt.lines)
+			}
+		})
+	}
+}
+
+func TestKMLCoordinates(t *testing.T) {
+	tests := []struct {
+		coords []models.Coordinates
+		want   string
+	}{
+		{nil, ""},
+		{[]models.Coordinates{{Lat: 51.5, Lon: -0.1}}, "-0.100000,51.500000"},
+		{[]models.Coordinates{{Lat: 51.5, Lon: -0.1}, {Lat: 48.9, Lon: 2.4}}, "-0.100000,51.500000 2.400000,48.900000"},
+	}
+	for _, tt := range tests {
+		if got := kmlCoordinates(tt.coords); got != tt.want {
+			t.Errorf("kmlCoordinates(%v) = %q, want %q", tt.coords, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package testutil holds the fixtures shared by the tests of several packages, such as a fake upstream API.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// APIResponses are the bodies of a minimal API with a single artist, by endpoint.
+var APIResponses = map[string]string{
+	"artists":   `[{"id":1,"name":"Queen","members":["Freddie Mercury"]}]`,
+	"locations": `{"index":[{"id":1,"locations":["london-uk"]}]}`,
+	"relation":  `{"index":[{"id":1,"datesLocations":{"london-uk":["01-01-2020"]}}]}`,
+	"dates":     `{"index":[{"id":1,"dates":["01-01-2020"]}]}`,
+}
+
+// API is a fake Groupie Trackers API answering each endpoint with a fixed body.
+type API struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]string
+	onRequest func(r *http.Request)
+}
+
+// NewAPI starts a fake API serving the given bodies by endpoint, e.g. APIResponses, and closes it when the test ends.
+func NewAPI(t testing.TB, responses map[string]string) *API {
+	t.Helper()
+	api := &API{responses: responses}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.serve))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// SetResponses replaces the bodies served by the API, so the next refresh gets different data.
+func (a *API) SetResponses(responses map[string]string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.responses = responses
+}
+
+// OnRequest sets a function called with every request before it is answered, e.g. to hold it until released.
+func (a *API) OnRequest(fn func(r *http.Request)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onRequest = fn
+}
+
+func (a *API) serve(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	responses, onRequest := a.responses, a.onRequest
+	a.mu.Unlock()
+
+	if onRequest != nil {
+		onRequest(r)
+	}
+	body, ok := responses[strings.TrimPrefix(r.URL.Path, "/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(body))
+}
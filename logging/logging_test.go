@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// capture makes a logger writing to the returned buffer the default one for the duration of the test.
+func capture(t *testing.T, level, format string) *bytes.Buffer {
+	t.Helper()
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, level, format); err != nil {
+		t.Fatalf("Setup(%q, %q) error: %v", level, format, err)
+	}
+	return &buf
+}
+
+func TestSetupInvalid(t *testing.T) {
+	tests := []struct {
+		name, level, format string
+	}{
+		{"unknown level", "verbose", "text"},
+		{"unknown format", "info", "xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := slog.Default()
+			if err := Setup(&bytes.Buffer{}, tt.level, tt.format); err == nil {
+				t.Errorf("Setup(%q, %q) succeeded, want an error", tt.level, tt.format)
+			}
+			if slog.Default() != previous {
+				t.Error("a failed Setup replaced the default logger")
+			}
+		})
+	}
+}
+
+func TestRequestIDAttribute(t *testing.T) {
+	buf := capture(t, "info", "json")
+	ctx := WithRequestID(context.Background(), "abc-123")
+
+	slog.InfoContext(ctx, "With ID", "key", "value")
+	slog.With("component", "test").WarnContext(ctx, "With ID and attributes")
+	slog.Info("Without ID")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d log lines, want 3:\n%s", len(lines), buf)
+	}
+	want := []string{"abc-123", "abc-123", ""}
+	for i, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		id, _ := record["request_id"].(string)
+		if id != want[i] {
+			t.Errorf("line %d: request_id = %q, want %q", i, id, want[i])
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	buf := capture(t, "warn", "text")
+	slog.Info("Hidden")
+	slog.Warn("Shown")
+
+	if out := buf.String(); strings.Contains(out, "Hidden") || !strings.Contains(out, "Shown") {
+		t.Errorf("output at level warn:\n%s", out)
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	buf := capture(t, "info", "text")
+	slog.InfoContext(WithRequestID(context.Background(), "abc-123"), "Hello")
+
+	if out := buf.String(); !strings.Contains(out, "msg=Hello") || !strings.Contains(out, "request_id=abc-123") {
+		t.Errorf("text output = %q", out)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("RequestID of an empty context = %q, want empty", id)
+	}
+	if id := RequestID(WithRequestID(context.Background(), "abc")); id != "abc" {
+		t.Errorf("RequestID = %q, want abc", id)
+	}
+
+	a, b := NewRequestID(), NewRequestID()
+	if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(a) {
+		t.Errorf("NewRequestID() = %q, want 16 hexadecimal characters", a)
+	}
+	if a == b {
+		t.Errorf("NewRequestID() returned %q twice", a)
+	}
+}
@@ -2,70 +2,194 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"groopie_local/config"
 	"groopie_local/handlers"
-	"log"
+	"groopie_local/logging"
+	"groopie_local/metrics"
+	"groopie_local/services"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
-	"time"
 )
 
-// Middleware to recover from panics in handlers
-func recoverMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("Recovered from panic: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+// fatal logs an error and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 func main() {
-	// Set the port from environment variable or default to 8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-		log.Println("No PORT environment variable detected, defaulting to 8080")
+	// "config print" shows the effective configuration instead of starting the server.
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := config.Command(os.Args[2:], os.Stdout); err != nil {
+			fatal("Invalid configuration", "error", err)
+		}
+		return
+	}
+
+	// Load the configuration from the defaults, the config file, the environment and the flags.
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.Log.Level, cfg.Log.Format); err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	services.ConfigureAPI(cfg.API.BaseURL, cfg.API.Timeout.Std(), cfg.API.CacheTTL.Std())
+	services.ConfigureBreaker(cfg.API.BreakerThreshold, cfg.API.BreakerCooldown.Std())
+	services.ConfigureGeocoding(cfg.Geocoding.URL, cfg.Geocoding.Interval.Std(), cfg.Geocoding.Timeout.Std())
+	// Fingerprint the static files for the templates, then parse the templates once;
+	// when they are read from disk, reload them whenever they change.
+	static, err := handlers.StaticHandler(assetFS("static", cfg.Paths.Static), cfg.Paths.Static != "")
+	if err != nil {
+		fatal("Invalid static files", "error", err)
+	}
+	if err := handlers.LoadTemplates(assetFS("templates", cfg.Paths.Templates), cfg.Paths.Templates != ""); err != nil {
+		fatal("Invalid templates", "error", err)
 	}
 
-	// Check if the static directory exists
-	if _, err := os.Stat("./static"); os.IsNotExist(err) {
-		log.Fatalf("Static directory not found")
+	// Load the outgoing webhooks notified on dataset changes, if configured.
+	if path := cfg.Webhooks.File; path != "" {
+		hooks, err := services.LoadWebhooks(path)
+		if err != nil {
+			fatal("Failed to load webhooks", "error", err)
+		}
+		services.ConfigureWebhooks(hooks)
+		slog.Info("Loaded webhooks", "count", len(hooks), "file", path)
 	}
 
+	// Open the user store of the configured kind, "file" or "bolt".
+	store, err := services.OpenUserStore(cfg.Users.Store, cfg.UserStorePath())
+	if err != nil {
+		fatal("Failed to open user store", "error", err)
+	}
+	defer store.Close()
+	services.ConfigureUserStore(store)
+
+	// Sign sessions with the configured secret so they survive restarts.
+	if cfg.Users.SessionSecret != "" {
+		services.SetSessionSecret([]byte(cfg.Users.SessionSecret))
+	} else {
+		slog.Warn("No session secret configured, sessions will not survive a restart")
+	}
+
+	// Build the absolute links of the feeds from the public address, if configured.
+	handlers.SetPublicURL(cfg.Server.PublicURL)
+
+	// Open the administration pages to whoever has the admin token, if any.
+	handlers.SetAdminToken(cfg.Admin.Token)
+
+	// Push dataset changes to the connected event streams.
+	services.OnChange(handlers.BroadcastChanges)
+	// Notify users whose saved searches match different artists after a refresh.
+	services.OnChange(handlers.CheckSavedSearches)
+
 	mux := http.NewServeMux()
 
-	// Serve static files
-	fs := http.FileServer(http.Dir("./static"))
-	mux.Handle("/static/", http.StripPrefix("/static/", fs))
+	// Serve static files, embedded in the binary unless a directory is configured, with caching headers
+	mux.Handle("/static/", static)
 
 	// Register route handlers with panic recovery middleware
-	mux.HandleFunc("/artist/", handlers.ArtistHandler) // Artist data
-	mux.HandleFunc("/home", handlers.HomeHandler)      // Home page
-	mux.HandleFunc("/search", handlers.SearchHandler)  // Search page
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/artist/", handlers.HandlerFunc(handlers.ArtistHandler)) // Artist data
+	mux.Handle("/home", handlers.HandlerFunc(handlers.HomeHandler))      // Home page
+	mux.Handle("/search", handlers.HandlerFunc(handlers.SearchHandler))  // Search page
+
+	// Exports and feeds
+	mux.Handle("/tours.geojson", handlers.HandlerFunc(handlers.ToursHandler)) // Filtered tours as GeoJSON
+	mux.Handle("/tours.kml", handlers.HandlerFunc(handlers.ToursHandler))     // Filtered tours as KML
+	mux.Handle("/feed.atom", handlers.HandlerFunc(handlers.FeedHandler))      // Atom feed of new artists and concerts
+	mux.Handle("/feed.rss", handlers.HandlerFunc(handlers.FeedHandler))       // RSS feed of new artists and concerts
+
+	// User accounts
+	mux.Handle("/register", handlers.HandlerFunc(handlers.RegisterHandler))    // Registration form
+	mux.Handle("/login", handlers.HandlerFunc(handlers.LoginHandler))          // Login form
+	mux.Handle("/logout", handlers.HandlerFunc(handlers.LogoutHandler))        // Logout (POST)
+	mux.Handle("/favorites/", handlers.HandlerFunc(handlers.FavoriteHandler))  // Add or remove a favorite (POST)
+	mux.Handle("/following/", handlers.HandlerFunc(handlers.FollowHandler))    // Follow or unfollow an artist (POST)
+	mux.Handle("/my-artists", handlers.HandlerFunc(handlers.MyArtistsHandler)) // Favorite and followed artists
+
+	// Saved searches
+	mux.Handle("/searches", handlers.HandlerFunc(handlers.SavedSearchesHandler))                 // Saved searches and notifications
+	mux.Handle("/searches/", handlers.HandlerFunc(handlers.SavedSearchHandler))                  // Rename, notify or delete a saved search (POST)
+	mux.Handle("/notifications/clear", handlers.HandlerFunc(handlers.ClearNotificationsHandler)) // Clear notifications (POST)
+	mux.Handle("/s/", handlers.HandlerFunc(handlers.ShortLinkHandler))                           // Short link to a shared search
+
+	// Locations and countries
+	mux.Handle("/location/", handlers.HandlerFunc(handlers.LocationHandler)) // Artists and concerts at a location
+	mux.Handle("/country/", handlers.HandlerFunc(handlers.CountryHandler))   // Artists and concerts in a country
+	mux.Handle("/member/", handlers.HandlerFunc(handlers.MemberHandler))     // Artists a band member plays in
+
+	// Comparison
+	mux.Handle("/compare", handlers.HandlerFunc(handlers.CompareHandler)) // Side-by-side comparison of artists
+
+	// Calendar
+	mux.Handle("/calendar", handlers.HandlerFunc(handlers.CalendarHandler)) // Month, week and day views of the concerts
+
+	// Statistics
+	mux.Handle("/stats", handlers.HandlerFunc(handlers.StatsHandler)) // Dataset statistics dashboard
+
+	// Festivals and shared bills
+	mux.Handle("/festivals", handlers.HandlerFunc(handlers.FestivalsHandler))      // Events shared by several artists
+	mux.Handle("/festivals.json", handlers.HandlerFunc(handlers.FestivalsHandler)) // Same events as JSON
+
+	// JSON API
+	mux.Handle("/api/v1/artists", handlers.HandlerFunc(handlers.APIArtistsHandler))  // Every artist
+	mux.Handle("/api/v1/artists/", handlers.HandlerFunc(handlers.APIArtistsHandler)) // One artist and its similar artists
+	mux.Handle("/api/v1/stats", handlers.HandlerFunc(handlers.APIStatsHandler))      // Dataset statistics
+	mux.Handle("/api/v1/compare", handlers.HandlerFunc(handlers.APICompareHandler))  // Comparison of artists
+
+	// Live updates
+	mux.Handle("/events", handlers.HandlerFunc(handlers.EventsHandler)) // Server-Sent Events stream
+
+	// Monitoring
+	mux.HandleFunc("/metrics", metrics.Handler)         // Prometheus metrics
+	mux.HandleFunc("/healthz", handlers.HealthzHandler) // Liveness probe
+	mux.HandleFunc("/readyz", handlers.ReadyzHandler)   // Readiness probe: data loaded and fresh
+	mux.HandleFunc("/status", handlers.StatusHandler)   // Data age, refresh error, circuit state and build info
+
+	// Administration, only with an admin token
+	mux.Handle("/admin/changes", handlers.RequireAdmin(handlers.ChangesHandler))        // Dataset change history
+	mux.Handle("/admin/changes.json", handlers.RequireAdmin(handlers.ChangesHandler))   // Dataset change history as JSON
+	mux.Handle("/admin/webhooks", handlers.RequireAdmin(handlers.WebhooksHandler))      // Webhook delivery log
+	mux.Handle("/admin/webhooks.json", handlers.RequireAdmin(handlers.WebhooksHandler)) // Webhook delivery log as JSON
+
+	mux.Handle("/", handlers.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		if r.URL.Path == "/" {
 			handlers.WelcomeHandler(w, r) // Serve the welcome page only for "/"
-			return
+			return nil
 		}
-		handlers.NotFoundHandler(w, r) // Serve 404 error for any other undefined route
-	})
+		return handlers.NotFoundHandler(w, r) // Serve 404 error for any other undefined route
+	}))
 
-	// Wrap handlers with panic recovery
-	handler := recoverMiddleware(mux)
+	// Wrap handlers with panic recovery, count every request including the recovered ones,
+	// and log each of them with the request ID passed down to the handlers and services
+	handler := accessLogMiddleware(metricsMiddleware(mux, recoverMiddleware(mux)))
 
 	// Create the HTTP server with timeouts
 	server := &http.Server{
-		Addr:         ":" + port,
+		Addr:         ":" + strconv.Itoa(cfg.Server.Port),
 		Handler:      handler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  15 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout.Std(),
+		WriteTimeout: cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Std(),
 	}
+	// Close open event streams when shutting down so they don't delay it.
+	server.RegisterOnShutdown(handlers.CloseEventStreams)
+
+	// Refresh the data in the background so changes are pushed without waiting for a request.
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	go services.StartAutoRefresh(refreshCtx)
+	// Geocode the concert locations in the background, so the tour exports never wait for Nominatim.
+	go services.StartGeocoding(refreshCtx)
 
 	// Channel to listen for interrupt or termination signals
 	stop := make(chan os.Signal, 1)
@@ -73,23 +197,23 @@ func main() {
 
 	// Start the server in a separate goroutine
 	go func() {
-		log.Printf("Server started on :%s", port)
+		slog.Info("Server started", "port", cfg.Server.Port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			fatal("Server failed to start", "error", err)
 		}
 	}()
 
 	// Wait for interrupt signal
 	<-stop
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server...")
 
 	// Gracefully shut down the server with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", "error", err)
 	}
 
-	log.Println("Server exited gracefully")
-}
\ No newline at end of file
+	slog.Info("Server exited gracefully")
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// resetRegistry empties the registry for the duration of a test, so metric names can be registered again by -count.
+func resetRegistry(t *testing.T) {
+	t.Helper()
+	registryLock.Lock()
+	saved := registry
+	registry = nil
+	registryLock.Unlock()
+	t.Cleanup(func() {
+		registryLock.Lock()
+		registry = saved
+		registryLock.Unlock()
+	})
+}
+
+// output returns what m writes in the exposition format.
+func output(m metric) string {
+	var buf bytes.Buffer
+	m.write(&buf)
+	return buf.String()
+}
+
+func TestCounter(t *testing.T) {
+	resetRegistry(t)
+	c := NewCounter("test_counter_total", "Counted things,\nby kind.", "kind")
+	c.Inc("b")
+	c.Add(2.5, "a")
+	c.Inc("b")
+
+	want := `# HELP test_counter_total Counted things,\nby kind.
+# TYPE test_counter_total counter
+test_counter_total{kind="a"} 2.5
+test_counter_total{kind="b"} 2
+`
+	if got := output(c); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+	if got := c.Value("b"); got != 2 {
+		t.Errorf("Value(b) = %g, want 2", got)
+	}
+	if got := c.Value("missing"); got != 0 {
+		t.Errorf("Value(missing) = %g, want 0", got)
+	}
+}
+
+func TestCounterWithoutLabels(t *testing.T) {
+	resetRegistry(t)
+	c := NewCounter("test_unlabeled_total", "Unlabeled.")
+	c.Inc()
+
+	want := "# HELP test_unlabeled_total Unlabeled.\n# TYPE test_unlabeled_total counter\ntest_unlabeled_total 1\n"
+	if got := output(c); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGaugeFunc(t *testing.T) {
+	resetRegistry(t)
+	value := 3.0
+	g := NewGaugeFunc("test_gauge", "A gauge.", func() float64 { return value })
+	value = 7
+
+	want := "# HELP test_gauge A gauge.\n# TYPE test_gauge gauge\ntest_gauge 7\n"
+	if got := output(g); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	resetRegistry(t)
+	h := NewHistogram("test_duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v, "/a")
+	}
+
+	// Buckets are cumulative and include their upper bound; +Inf counts every observation.
+	want := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 2
+test_duration_seconds_bucket{route="/a",le="1"} 3
+test_duration_seconds_bucket{route="/a",le="+Inf"} 4
+test_duration_seconds_sum{route="/a"} 3.65
+test_duration_seconds_count{route="/a"} 4
+`
+	if got := output(h); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	resetRegistry(t)
+	c := NewCounter("test_escaped_total", `Help with a \ backslash.`, "value")
+	c.Inc("back\\slash \"quoted\"\nnewline")
+
+	want := `# HELP test_escaped_total Help with a \\ backslash.
+# TYPE test_escaped_total counter
+test_escaped_total{value="back\\slash \"quoted\"\nnewline"} 1
+`
+	if got := output(c); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{0.005, "0.005"},
+		{2.5, "2.5"},
+		{1e21, "1e+21"},
+	}
+	for _, tt := range tests {
+		if got := formatFloat(tt.v); got != tt.want {
+			t.Errorf("formatFloat(%g) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestLabelCountMismatch(t *testing.T) {
+	resetRegistry(t)
+	c := NewCounter("test_mismatch_total", "Mismatch.", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc with too few label values did not panic")
+		}
+	}()
+	c.Inc("only one")
+}
+
+func TestDuplicateRegistration(t *testing.T) {
+	resetRegistry(t)
+	NewCounter("test_duplicate_total", "Duplicate.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate name did not panic")
+		}
+	}()
+	NewCounter("test_duplicate_total", "Duplicate.")
+}
+
+func TestHandler(t *testing.T) {
+	resetRegistry(t)
+	NewCounter("test_handler_total", "Served.").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the text exposition format", got)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "# TYPE test_handler_total counter\ntest_handler_total 1\n") {
+		t.Errorf("body does not contain the registered counter:\n%s", body)
+	}
+}
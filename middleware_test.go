@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"groopie_local/logging"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs makes a JSON logger writing to the returned buffer the default one for the duration of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	if err := logging.Setup(&buf, "debug", "json"); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// logRecords decodes the JSON log lines written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("invalid log line: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string // X-Request-ID sent by the client.
+		status    int
+		reused    bool // Whether the header is kept as the request ID.
+		wantLevel string
+	}{
+		{name: "generated ID", status: http.StatusOK, wantLevel: "INFO"},
+		{name: "client ID", header: "proxy-42.a_b", status: http.StatusOK, reused: true, wantLevel: "INFO"},
+		{name: "invalid client ID", header: "bad id with spaces", status: http.StatusOK, wantLevel: "INFO"},
+		{name: "client error", status: http.StatusNotFound, wantLevel: "INFO"},
+		{name: "server error", status: http.StatusBadGateway, wantLevel: "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLogs(t)
+			var handlerID string
+			handler := accessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerID = logging.RequestID(r.Context())
+				slog.InfoContext(r.Context(), "Handling")
+				w.WriteHeader(tt.status)
+				w.Write([]byte("hello"))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/artist/1", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get("X-Request-ID")
+			if !requestIDPattern.MatchString(id) {
+				t.Fatalf("X-Request-ID = %q, want a valid ID", id)
+			}
+			if tt.reused != (id == tt.header) {
+				t.Errorf("X-Request-ID = %q with %q sent, reused = %v", id, tt.header, !tt.reused)
+			}
+			if handlerID != id {
+				t.Errorf("request ID in the handler context = %q, want %q", handlerID, id)
+			}
+
+			records := logRecords(t, buf)
+			if len(records) != 2 {
+				t.Fatalf("got %d log records, want 2", len(records))
+			}
+			for _, record := range records {
+				if record["request_id"] != id {
+					t.Errorf("%v: request_id = %v, want %s", record["msg"], record["request_id"], id)
+				}
+			}
+			access := records[1]
+			if access["msg"] != "Request" || access["level"] != tt.wantLevel {
+				t.Errorf("access log = %v %v, want Request %s", access["level"], access["msg"], tt.wantLevel)
+			}
+			if access["status"] != float64(tt.status) || access["bytes"] != float64(5) || access["path"] != "/artist/1" {
+				t.Errorf("access log status, bytes, path = %v, %v, %v", access["status"], access["bytes"], access["path"])
+			}
+		})
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	buf := captureLogs(t)
+	handler := accessLogMiddleware(recoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	req.Header.Set("X-Request-ID", "panic-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	records := logRecords(t, buf)
+	if len(records) == 0 || records[0]["msg"] != "Recovered from panic" {
+		t.Fatalf("first log record = %v, want the recovered panic", records)
+	}
+	panicRecord := records[0]
+	if panicRecord["panic"] != "boom" || panicRecord["request_id"] != "panic-1" || panicRecord["level"] != "ERROR" {
+		t.Errorf("panic record = %v", panicRecord)
+	}
+	if stack, _ := panicRecord["stack"].(string); !strings.Contains(stack, "TestRecoverMiddleware") {
+		t.Errorf("stack does not show where the panic happened:\n%s", stack)
+	}
+}
+
+func TestMetricsMiddlewareRoutes(t *testing.T) {
+	routes := http.NewServeMux()
+	routes.HandleFunc("/artist/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+		}
+	})
+	handler := metricsMiddleware(routes, routes)
+
+	before := map[[3]string]float64{}
+	want := map[[3]string]float64{
+		{"/artist/", "GET", "200"}: 3,
+		{"/artist/", "GET", "404"}: 1,
+		{"", "GET", "404"}:         2, // Unmatched paths share one series whatever they are.
+	}
+	for labels := range want {
+		before[labels] = httpRequests.Value(labels[:]...)
+	}
+
+	for _, path := range []string{"/artist/1", "/artist/2", "/artist/some-band", "/artist/missing", "/random/1", "/random/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	for labels, n := range want {
+		if got := httpRequests.Value(labels[:]...) - before[labels]; got != n {
+			t.Errorf("requests%v increased by %g, want %g", labels, got, n)
+		}
+	}
+	for _, path := range []string{"/artist/1", "/artist/some-band", "/random/1"} {
+		if got := httpRequests.Value(path, "GET", "200") + httpRequests.Value(path, "GET", "404"); got != 0 {
+			t.Errorf("the raw path %s was used as a route label", path)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConcertDateLayout is the layout used by the API for concert dates ("DD-MM-YYYY").
+const ConcertDateLayout = "02-01-2006"
+
+// Concert is a single performance of an artist at a location on a given date.
+type Concert struct {
+	Location string    `json:"location"`
+	Date     time.Time `json:"date"`
+}
+
+// ParseConcertDate parses a date formatted as "DD-MM-YYYY".
+// The leading "*" used by the dates endpoint is ignored.
+func ParseConcertDate(s string) (time.Time, error) {
+	return time.Parse(ConcertDateLayout, strings.TrimPrefix(strings.TrimSpace(s), "*"))
+}
+
+// Concerts flattens the relations into a list of concerts ordered by date.
+// Dates that cannot be parsed are skipped. Concerts on the same day are ordered by location.
+func (r *Relations) Concerts() []Concert {
+	var concerts []Concert
+	for location, dates := range r.DatesLocations {
+		for _, d := range dates {
+			date, err := ParseConcertDate(d)
+			if err != nil {
+				continue
+			}
+			concerts = append(concerts, Concert{Location: location, Date: date})
+		}
+	}
+
+	sort.Slice(concerts, func(i, j int) bool {
+		if concerts[i].Date.Equal(concerts[j].Date) {
+			return concerts[i].Location < concerts[j].Location
+		}
+		return concerts[i].Date.Before(concerts[j].Date)
+	})
+	return concerts
+}
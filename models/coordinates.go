@@ -0,0 +1,7 @@
+package models
+
+// Coordinates is a geographic position in decimal degrees.
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
@@ -1,10 +1,12 @@
 package models
 
 type Filters struct {
-	SearchQuery              string
-	SearchType               string
-	CreationMin, CreationMax int
-	AlbumMin, AlbumMax       int
-	BandMembers              []string
-	PerformanceLocations     []string
+	SearchQuery          string   `json:"searchQuery,omitempty"`
+	SearchType           string   `json:"searchType,omitempty"`
+	CreationMin          int      `json:"creationMin"`
+	CreationMax          int      `json:"creationMax"`
+	AlbumMin             int      `json:"albumMin"`
+	AlbumMax             int      `json:"albumMax"`
+	BandMembers          []string `json:"bandMembers,omitempty"`
+	PerformanceLocations []string `json:"locations,omitempty"`
 }
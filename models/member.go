@@ -0,0 +1,53 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Member is a person playing in one or more artists. The same person is recognised across
+// artists by the slug of their name.
+type Member struct {
+	Name    string   `json:"name"`
+	Slug    string   `json:"slug"`
+	Artists []Artist `json:"artists,omitempty"`
+}
+
+// MemberSlug returns the URL identifier of a member name: lowercase letters and digits
+// separated by dashes, e.g. "freddie-mercury" for "Freddie Mercury".
+func MemberSlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// MemberList returns the members of the artist with their slugs, without their other artists.
+func (a Artist) MemberList() []Member {
+	members := make([]Member, 0, len(a.Members))
+	for _, name := range a.Members {
+		members = append(members, Member{Name: name, Slug: MemberSlug(name)})
+	}
+	return members
+}
+
+// InOtherArtists returns the artists of the member other than the one with the given ID.
+func (m Member) InOtherArtists(artistID int) []Artist {
+	var others []Artist
+	for _, artist := range m.Artists {
+		if artist.ID != artistID {
+			others = append(others, artist)
+		}
+	}
+	return others
+}
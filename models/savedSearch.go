@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SavedSearch is a named set of filters saved by a user and shareable through its short code.
+type SavedSearch struct {
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Filters   Filters   `json:"filters"`
+	Notify    bool      `json:"notify"`
+	ResultIDs []int     `json:"resultIds"` // Artist IDs matched when the search was last checked.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Notification tells a user that the results of one of their saved searches changed.
+type Notification struct {
+	Time       time.Time `json:"time"`
+	SearchCode string    `json:"searchCode"`
+	Message    string    `json:"message"`
+}
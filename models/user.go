@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type User struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"passwordHash"`
+	Favorites    []int     `json:"favorites"`
+	Following    []int     `json:"following"`
+	CreatedAt    time.Time `json:"createdAt"`
+	// SessionVersion is part of every session token of the user; incrementing it, e.g. on logout, ends them all.
+	SessionVersion int `json:"sessionVersion"`
+
+	SavedSearches []SavedSearch  `json:"savedSearches"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// IsFavorite reports whether the artist with the given ID is one of the user's favorites.
+func (u *User) IsFavorite(artistID int) bool {
+	return containsInt(u.Favorites, artistID)
+}
+
+// IsFollowing reports whether the user follows the artist with the given ID.
+func (u *User) IsFollowing(artistID int) bool {
+	return containsInt(u.Following, artistID)
+}
+
+// SavedSearch returns the saved search with the given code, or nil if the user has none.
+func (u *User) SavedSearch(code string) *SavedSearch {
+	for i := range u.SavedSearches {
+		if u.SavedSearches[i].Code == code {
+			return &u.SavedSearches[i]
+		}
+	}
+	return nil
+}
+
+// containsInt checks if a slice contains the specified value.
+func containsInt(slice []int, value int) bool {
+	for _, item := range slice {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
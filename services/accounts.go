@@ -0,0 +1,258 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"groopie_local/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SessionTTL is how long a login stays valid.
+const SessionTTL = 7 * 24 * time.Hour
+
+// minPasswordLength is the minimum number of characters of a password.
+const minPasswordLength = 8
+
+// maxPasswordLength is the maximum number of bytes of a password, the most bcrypt accepts.
+const maxPasswordLength = 72
+
+var (
+	// ErrInvalidUsername is returned when a username does not match usernamePattern.
+	ErrInvalidUsername = errors.New("username must be 3 to 32 letters, digits, '.', '-' or '_'")
+	// ErrWeakPassword is returned when a password is shorter than minPasswordLength.
+	ErrWeakPassword = errors.New("password must be at least 8 characters long")
+	// ErrPasswordTooLong is returned when a password is longer than maxPasswordLength bytes.
+	ErrPasswordTooLong = errors.New("password must be at most 72 bytes long")
+	// ErrInvalidCredentials is returned when a login fails, without telling whether the user exists.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidSession is returned for session tokens that are malformed, forged or expired.
+	ErrInvalidSession = errors.New("invalid session")
+	// ErrAccountsDisabled is returned when no user store is configured.
+	ErrAccountsDisabled = errors.New("user accounts are not enabled")
+)
+
+// usernamePattern restricts usernames to characters that are safe in URLs and file names.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,32}$`)
+
+var (
+	// users is the store holding the accounts, set by ConfigureUserStore.
+	users UserStore
+	// accountLock serialises read-modify-write updates of users.
+	accountLock sync.Mutex
+	// sessionSecret signs the session tokens.
+	sessionSecret = randomSecret()
+	// dummyHash is compared against when the username is unknown.
+	dummyHash = sync.OnceValue(func() []byte {
+		hash, _ := bcrypt.GenerateFromPassword(randomSecret()[:16], bcrypt.DefaultCost)
+		return hash
+	})
+)
+
+// ConfigureUserStore sets the store used for user accounts. It should be called once at startup.
+func ConfigureUserStore(store UserStore) {
+	users = store
+
+	searchOwnersLock.Lock()
+	defer searchOwnersLock.Unlock()
+	searchOwners = nil
+}
+
+// SetSessionSecret sets the key signing session tokens. Without it, a random key is used
+// and every session is invalidated when the server restarts.
+func SetSessionSecret(secret []byte) {
+	sessionSecret = secret
+}
+
+// Register creates a new account with a bcrypt hash of the password.
+func Register(username, password string) (models.User, error) {
+	if users == nil {
+		return models.User{}, ErrAccountsDisabled
+	}
+	if !usernamePattern.MatchString(username) {
+		return models.User{}, ErrInvalidUsername
+	}
+	if len(password) < minPasswordLength {
+		return models.User{}, ErrWeakPassword
+	}
+	if len(password) > maxPasswordLength {
+		return models.User{}, ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	user := models.User{Username: username, PasswordHash: string(hash), CreatedAt: time.Now()}
+	if err := users.CreateUser(user); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// Authenticate checks the password of the given user.
+func Authenticate(username, password string) (models.User, error) {
+	if users == nil {
+		return models.User{}, ErrAccountsDisabled
+	}
+	user, err := users.GetUser(username)
+	if errors.Is(err, ErrUserNotFound) {
+		// Compare anyway so unknown usernames take as long as wrong passwords.
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// GetUser returns the account with the given username.
+func GetUser(username string) (models.User, error) {
+	if users == nil {
+		return models.User{}, ErrAccountsDisabled
+	}
+	return users.GetUser(username)
+}
+
+// ListUsers returns every account.
+func ListUsers() ([]models.User, error) {
+	if users == nil {
+		return nil, ErrAccountsDisabled
+	}
+	return users.ListUsers()
+}
+
+// UpdateUser loads the account, applies update to it and stores the result.
+// Updates are serialised so concurrent changes to the same account are not lost.
+func UpdateUser(username string, update func(user *models.User)) (models.User, error) {
+	if users == nil {
+		return models.User{}, ErrAccountsDisabled
+	}
+	accountLock.Lock()
+	defer accountLock.Unlock()
+
+	user, err := users.GetUser(username)
+	if err != nil {
+		return models.User{}, err
+	}
+	update(&user)
+	if err := users.UpdateUser(user); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// SetFavorite adds the artist to, or removes it from, the user's favorites.
+func SetFavorite(username string, artistID int, favorite bool) (models.User, error) {
+	return UpdateUser(username, func(user *models.User) {
+		user.Favorites = setInt(user.Favorites, artistID, favorite)
+	})
+}
+
+// SetFollowing adds the artist to, or removes it from, the artists followed by the user.
+func SetFollowing(username string, artistID int, following bool) (models.User, error) {
+	return UpdateUser(username, func(user *models.User) {
+		user.Following = setInt(user.Following, artistID, following)
+	})
+}
+
+// setInt returns the slice with value present if include is true, or absent otherwise.
+func setInt(slice []int, value int, include bool) []int {
+	var result []int
+	for _, item := range slice {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	if include {
+		result = append(result, value)
+	}
+	return result
+}
+
+// NewSessionToken returns a signed token identifying the user until SessionTTL has elapsed or EndSessions is called.
+// The token has the form base64(username|version|expiry).base64(hmac), where version is the user's SessionVersion.
+func NewSessionToken(user models.User) string {
+	payload := user.Username + "|" + strconv.Itoa(user.SessionVersion) + "|" + strconv.FormatInt(time.Now().Add(SessionTTL).Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + signSession(encoded)
+}
+
+// ParseSessionToken verifies a token created by NewSessionToken and returns the user it identifies.
+// Tokens that expired or were ended by EndSessions, or whose user no longer exists, are invalid.
+func ParseSessionToken(token string) (models.User, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signSession(encoded))) {
+		return models.User{}, ErrInvalidSession
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return models.User{}, ErrInvalidSession
+	}
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 3 {
+		return models.User{}, ErrInvalidSession
+	}
+	version, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return models.User{}, ErrInvalidSession
+	}
+	expires, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return models.User{}, ErrInvalidSession
+	}
+
+	user, err := GetUser(fields[0])
+	if errors.Is(err, ErrUserNotFound) || (err == nil && user.SessionVersion != version) {
+		return models.User{}, ErrInvalidSession
+	}
+	return user, err
+}
+
+// EndSessions invalidates every session token of the user, on every device.
+func EndSessions(username string) error {
+	_, err := UpdateUser(username, func(user *models.User) {
+		user.SessionVersion++
+	})
+	return err
+}
+
+// CSRFToken returns the token that forms posted with the given session token must include.
+// It is derived from the session token, which other sites cannot read, and changes with every login.
+func CSRFToken(sessionToken string) string {
+	return signSession("csrf|" + sessionToken)
+}
+
+// ValidCSRFToken reports whether token is the CSRF token of the session token.
+func ValidCSRFToken(sessionToken, token string) bool {
+	return sessionToken != "" && hmac.Equal([]byte(token), []byte(CSRFToken(sessionToken)))
+}
+
+// signSession returns the base64-encoded HMAC-SHA256 of the encoded session payload.
+func signSession(encoded string) string {
+	mac := hmac.New(sha256.New, sessionSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// randomSecret returns 32 random bytes.
+func randomSecret() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("services: cannot generate session secret: " + err.Error())
+	}
+	return b
+}
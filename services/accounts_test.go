@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"groopie_local/models"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionToken(t *testing.T) {
+	store, err := NewFileUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []models.User{{Username: "alice"}, {Username: "bob", SessionVersion: 3}} {
+		if err := store.CreateUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ConfigureUserStore(store)
+	defer ConfigureUserStore(nil)
+
+	alice := NewSessionToken(models.User{Username: "alice"})
+	encoded, signature, _ := strings.Cut(alice, ".")
+	// sign builds a validly signed token from a raw payload.
+	sign := func(payload string) string {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+		return encoded + "." + signSession(encoded)
+	}
+	expiry := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name  string
+		token string
+		want  string // Username, or "" for an invalid token.
+	}{
+		{name: "valid", token: alice, want: "alice"},
+		{name: "current version", token: NewSessionToken(models.User{Username: "bob", SessionVersion: 3}), want: "bob"},
+		{name: "ended version", token: NewSessionToken(models.User{Username: "bob", SessionVersion: 2})},
+		{name: "unknown user", token: NewSessionToken(models.User{Username: "carol"})},
+		{name: "tampered signature", token: encoded + "." + strings.Repeat("A", len(signature))},
+		{name: "missing signature", token: encoded},
+		{name: "payload of another user", token: base64.RawURLEncoding.EncodeToString([]byte("bob|3|"+expiry)) + "." + signature},
+		{name: "signed payload", token: sign("alice|0|" + expiry), want: "alice"},
+		{name: "expired", token: sign("alice|0|1")},
+		{name: "without version", token: sign("alice|" + expiry)},
+		{name: "invalid version", token: sign("alice|x|" + expiry)},
+		{name: "extra field", token: sign("alice|0|" + expiry + "|x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := ParseSessionToken(tt.token)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidSession) {
+					t.Errorf("ParseSessionToken() = %q, %v, want ErrInvalidSession", user.Username, err)
+				}
+				return
+			}
+			if err != nil || user.Username != tt.want {
+				t.Errorf("ParseSessionToken() = %q, %v, want %q", user.Username, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndSessions(t *testing.T) {
+	store, err := NewFileUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ConfigureUserStore(store)
+	defer ConfigureUserStore(nil)
+
+	user, err := Register("alice", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := NewSessionToken(user)
+	if _, err := ParseSessionToken(before); err != nil {
+		t.Fatalf("ParseSessionToken() = %v before logging out", err)
+	}
+
+	if err := EndSessions("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSessionToken(before); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("ParseSessionToken() = %v after EndSessions, want ErrInvalidSession", err)
+	}
+
+	// Logging in again opens a new session.
+	user, err = Authenticate("alice", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSessionToken(NewSessionToken(user)); err != nil {
+		t.Errorf("ParseSessionToken() = %v for a new session", err)
+	}
+}
+
+func TestCSRFToken(t *testing.T) {
+	session := NewSessionToken(models.User{Username: "alice"})
+	other := NewSessionToken(models.User{Username: "bob"})
+	token := CSRFToken(session)
+
+	tests := []struct {
+		name           string
+		session, token string
+		want           bool
+	}{
+		{"matching", session, token, true},
+		{"other session", other, token, false},
+		{"empty token", session, "", false},
+		{"no session", "", CSRFToken(""), false},
+		{"session token itself", session, session, false},
+	}
+	for _, tt := range tests {
+		if got := ValidCSRFToken(tt.session, tt.token); got != tt.want {
+			t.Errorf("%s: ValidCSRFToken() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
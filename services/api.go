@@ -4,21 +4,73 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"groopie_local/logging"
 	"groopie_local/models"
+	"hash/fnv"
+	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	// cache stores the merged data for artists to avoid repeated API calls.
 	cache []models.ArtistFull
-	// cacheLock ensures thread-safe access to the cache.
+	// artistIndex maps each artist ID of the cache to its position, so artists are looked up without a scan.
+	artistIndex map[int]int
+	// cacheLock ensures thread-safe access to the cache, artistIndex and stats. It is only held to read them
+	// or to swap them after a refresh, never while the data is fetched.
 	cacheLock sync.Mutex
-	// lastCacheTime tracks the last time the cache was updated.
-	lastCacheTime time.Time
+	// refreshLock serializes the refreshes, so concurrent reads of expired data fetch it only once.
+	refreshLock sync.Mutex
+	// cacheDigest is the hash of the data, to tell whether a refresh changed it. It is guarded by refreshLock.
+	cacheDigest uint64
+	// cacheState describes the cached data and its latest refresh. It is replaced after every refresh attempt,
+	// so health checks and metrics read it without waiting for a refresh in progress.
+	cacheState atomic.Pointer[cacheInfo]
 )
 
+// cacheInfo is a snapshot of the state of the cache.
+type cacheInfo struct {
+	// artists is the number of cached artists, and updated the time they were fetched.
+	artists int
+	updated time.Time
+	// generation is incremented whenever a refresh changes the cached data, at modified.
+	generation uint64
+	modified   time.Time
+	// attempt and err describe the latest refresh, successful or not.
+	attempt time.Time
+	err     error
+}
+
+// loadCacheInfo returns the current state of the cache, which is zero until the first refresh attempt.
+func loadCacheInfo() cacheInfo {
+	if info := cacheState.Load(); info != nil {
+		return *info
+	}
+	return cacheInfo{}
+}
+
+var (
+	// apiBaseURL is the root of the Groupie Trackers API endpoints.
+	apiBaseURL = "https://groupietrackers.herokuapp.com/api"
+	// apiTimeout bounds each request to the API.
+	apiTimeout = 10 * time.Second
+	// cacheTTL is how long the cached data is served before it is refreshed.
+	cacheTTL = 5 * time.Minute
+)
+
+// ConfigureAPI sets the base URL of the API, the timeout of its requests and how long its data is cached.
+// It must be called before the server starts.
+func ConfigureAPI(baseURL string, timeout, ttl time.Duration) {
+	apiBaseURL = strings.TrimSuffix(baseURL, "/")
+	apiTimeout = timeout
+	client.Timeout = timeout
+	cacheTTL = ttl
+}
+
 // client is a custom HTTP client configured with connection pooling and timeouts.
 // This improves performance by reusing connections and ensures API requests do not hang.
 var client = &http.Client{
@@ -30,13 +82,31 @@ var client = &http.Client{
 	},
 }
 
-// fetchFromAPI performs an HTTP GET request to the specified URL using a custom HTTP client,
+// fetchFromAPI performs an HTTP GET request to the given endpoint of the API (e.g. "artists") using a custom HTTP client,
 // with context-based timeout to avoid long waits.
 // It checks if the response status is 200 OK, and decodes the JSON response into the target interface.
 // It returns an error if the request fails or if the status code is not OK.
-func fetchFromAPI(url string, target interface{}) error {
+// The outcome and duration of the request are recorded in the upstream metrics, and the request ID carried by ctx
+// is logged and forwarded in the X-Request-ID header.
+// While the API circuit breaker is open, it fails fast with ErrCircuitOpen instead.
+func fetchFromAPI(ctx context.Context, endpoint string, target interface{}) (err error) {
+	url := apiBaseURL + "/" + endpoint
+	start := time.Now()
+	outcome := "network_error"
+	if !apiBreaker.Allow() {
+		outcome = "circuit_open"
+		observeUpstream(endpoint, outcome, start)
+		return ErrCircuitOpen
+	}
+	defer func() {
+		apiBreaker.Record(err)
+		observeUpstream(endpoint, outcome, start)
+		slog.DebugContext(ctx, "Upstream request", "endpoint", endpoint, "outcome", outcome, "duration", time.Since(start))
+	}()
+
 	// Create a context with timeout to ensure the request is cancelled if it takes too long.
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// The data is shared by every request, so the fetch goes on even if the client that triggered it goes away.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiTimeout)
 	defer cancel()
 
 	// Create a new HTTP request with the given context.
@@ -44,6 +114,9 @@ func fetchFromAPI(url string, target interface{}) error {
 	if err != nil {
 		return err
 	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
 
 	// Execute the request using the custom client.
 	resp, err := client.Do(req)
@@ -54,55 +127,61 @@ func fetchFromAPI(url string, target interface{}) error {
 
 	// Verify that the HTTP status is 200 OK.
 	if resp.StatusCode != http.StatusOK {
+		outcome = statusOutcome(resp.StatusCode)
 		return fmt.Errorf("error: received status code %d from %s", resp.StatusCode, url)
 	}
 
 	// Decode the JSON response into the target interface.
-	return json.NewDecoder(resp.Body).Decode(target)
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		outcome = "decode_error"
+		return err
+	}
+	outcome = "success"
+	return nil
 }
 
 // FetchArtists retrieves the list of artists from the external API.
 // It returns a slice of models.Artist and an error if the request or decoding fails.
-func FetchArtists() ([]models.Artist, error) {
+func FetchArtists(ctx context.Context) ([]models.Artist, error) {
 	var artists []models.Artist
-	err := fetchFromAPI("https://groupietrackers.herokuapp.com/api/artists", &artists)
+	err := fetchFromAPI(ctx, "artists", &artists)
 	return artists, err
 }
 
 // FetchLocations retrieves location data from the external API.
 // It returns a slice of models.Location and an error if the request or decoding fails.
-func FetchLocations() ([]models.Location, error) {
+func FetchLocations(ctx context.Context) ([]models.Location, error) {
 	var response struct {
 		Index []models.Location `json:"index"`
 	}
-	err := fetchFromAPI("https://groupietrackers.herokuapp.com/api/locations", &response)
+	err := fetchFromAPI(ctx, "locations", &response)
 	return response.Index, err
 }
 
 // FetchRelations retrieves relations data from the external API.
 // It returns a slice of models.Relations and an error if the request or decoding fails.
-func FetchRelations() ([]models.Relations, error) {
+func FetchRelations(ctx context.Context) ([]models.Relations, error) {
 	var response struct {
 		Index []models.Relations `json:"index"`
 	}
-	err := fetchFromAPI("https://groupietrackers.herokuapp.com/api/relation", &response)
+	err := fetchFromAPI(ctx, "relation", &response)
 	return response.Index, err
 }
 
 // FetchDates retrieves dates data from the external API.
 // It returns a slice of models.Date and an error if the request or decoding fails.
-func FetchDates() ([]models.Date, error) {
+func FetchDates(ctx context.Context) ([]models.Date, error) {
 	var response struct {
 		Index []models.Date `json:"index"`
 	}
-	err := fetchFromAPI("https://groupietrackers.herokuapp.com/api/dates", &response)
+	err := fetchFromAPI(ctx, "dates", &response)
 	return response.Index, err
 }
 
 // MergeData concurrently fetches artists, locations, relations, and dates data,
 // then merges them into a slice of models.ArtistFull by matching their IDs.
 // It returns the merged data or an error if any of the API calls fail.
-func MergeData() ([]models.ArtistFull, error) {
+func MergeData(ctx context.Context) ([]models.ArtistFull, error) {
 	var (
 		artists   []models.Artist
 		locations []models.Location
@@ -117,7 +196,7 @@ func MergeData() ([]models.ArtistFull, error) {
 	wg.Add(4)
 	go func() {
 		defer wg.Done()
-		a, err := FetchArtists()
+		a, err := FetchArtists(ctx)
 		if err != nil {
 			errChan <- fmt.Errorf("FetchArtists: %w", err)
 			return
@@ -126,7 +205,7 @@ func MergeData() ([]models.ArtistFull, error) {
 	}()
 	go func() {
 		defer wg.Done()
-		l, err := FetchLocations()
+		l, err := FetchLocations(ctx)
 		if err != nil {
 			errChan <- fmt.Errorf("FetchLocations: %w", err)
 			return
@@ -135,7 +214,7 @@ func MergeData() ([]models.ArtistFull, error) {
 	}()
 	go func() {
 		defer wg.Done()
-		r, err := FetchRelations()
+		r, err := FetchRelations(ctx)
 		if err != nil {
 			errChan <- fmt.Errorf("FetchRelations: %w", err)
 			return
@@ -144,7 +223,7 @@ func MergeData() ([]models.ArtistFull, error) {
 	}()
 	go func() {
 		defer wg.Done()
-		d, err := FetchDates()
+		d, err := FetchDates(ctx)
 		if err != nil {
 			errChan <- fmt.Errorf("FetchDates: %w", err)
 			return
@@ -190,19 +269,195 @@ func MergeData() ([]models.ArtistFull, error) {
 }
 
 // GetCachedData returns the cached merged artist data.
-// If the cache is empty or older than 5 minutes, it refreshes the cache by calling MergeData.
-// It uses a mutex to ensure thread safety.
-func GetCachedData() ([]models.ArtistFull, error) {
+// If the cache is empty or older than its time to live (5 minutes by default), it refreshes the cache by calling MergeData
+// and records what changed since the previous refresh. When the refresh fails, the stale data is returned if there is any.
+// While another refresh is in progress, expired data is served as is rather than waiting for it.
+func GetCachedData(ctx context.Context) ([]models.ArtistFull, error) {
+	data, fresh := cachedData()
+	if fresh {
+		cacheRequests.Inc("hit")
+		return data, nil
+	}
+	cacheRequests.Inc("miss")
+
+	if len(data) > 0 {
+		if !refreshLock.TryLock() {
+			return data, nil
+		}
+	} else {
+		refreshLock.Lock()
+	}
+	defer refreshLock.Unlock()
+
+	// The data may have been refreshed while waiting for the lock.
+	if data, fresh = cachedData(); fresh {
+		return data, nil
+	}
+	if err := refreshLocked(ctx); err != nil {
+		if len(data) == 0 {
+			return nil, err
+		}
+		slog.WarnContext(ctx, "Serving stale cached data", "age", time.Since(loadCacheInfo().updated), "error", err)
+		return data, nil
+	}
+	data, _ = cachedData()
+	return data, nil
+}
+
+// cachedData returns the cached data and whether it is still within its time to live.
+func cachedData() ([]models.ArtistFull, bool) {
+	cacheLock.Lock()
+	data := cache
+	cacheLock.Unlock()
+	return data, len(data) > 0 && time.Since(loadCacheInfo().updated) <= cacheTTL
+}
+
+// GetArtist returns the artist with the given ID from the cached data, refreshing the cache first if it expired.
+// found is false when the current data has no artist with that ID, whatever IDs the data source uses.
+func GetArtist(ctx context.Context, id int) (artist models.ArtistFull, found bool, err error) {
+	if _, err := GetCachedData(ctx); err != nil {
+		return models.ArtistFull{}, false, err
+	}
 	cacheLock.Lock()
 	defer cacheLock.Unlock()
+	i, found := artistIndex[id]
+	if !found {
+		return models.ArtistFull{}, false, nil
+	}
+	return cache[i], true, nil
+}
 
-	if len(cache) == 0 || time.Since(lastCacheTime) > 5*time.Minute {
-		data, err := MergeData()
-		if err != nil {
-			return nil, err
+// indexArtists maps the ID of each artist to its position in artists.
+func indexArtists(artists []models.ArtistFull) map[int]int {
+	index := make(map[int]int, len(artists))
+	for i, artist := range artists {
+		index[artist.Artist.ID] = i
+	}
+	return index
+}
+
+// Refresh reloads the cache from the API regardless of its age.
+func Refresh(ctx context.Context) error {
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+	return refreshLocked(ctx)
+}
+
+// refreshLocked fetches fresh data, replaces the cache along with the statistics computed from it,
+// and records the diff against the previous data. The caller must hold refreshLock; cacheLock is only
+// taken to swap the data, so readers are not held up by the upstream requests.
+func refreshLocked(ctx context.Context) error {
+	start := time.Now()
+	data, err := MergeData(ctx)
+	cacheRefreshDuration.Observe(time.Since(start).Seconds())
+	info := loadCacheInfo()
+	info.attempt, info.err = start, err
+	if err != nil {
+		cacheRefreshes.Inc("error")
+		cacheState.Store(&info)
+		return err
+	}
+	cacheRefreshes.Inc("success")
+	slog.InfoContext(ctx, "Refreshed cached data", "artists", len(data), "duration", time.Since(start))
+
+	index, dataStats := indexArtists(data), ComputeStats(data)
+	cacheLock.Lock()
+	previous := cache
+	cache, artistIndex, stats = data, index, dataStats
+	cacheLock.Unlock()
+
+	// The state is stored after the data is swapped, so a new generation never describes the previous data.
+	info.artists, info.updated = len(data), time.Now()
+	if digest := digestArtists(data); info.generation == 0 || digest != cacheDigest {
+		info.generation++
+		info.modified = info.updated
+		cacheDigest = digest
+	}
+	cacheState.Store(&info)
+
+	recordDiff(previous, data)
+	queueLocations(data)
+	return nil
+}
+
+// digestArtists returns a hash of the artists, which changes whenever any of their data does.
+func digestArtists(artists []models.ArtistFull) uint64 {
+	h := fnv.New64a()
+	if err := json.NewEncoder(h).Encode(artists); err != nil {
+		// Unreachable for plain data; a hash that never matches only costs a new generation.
+		return uint64(time.Now().UnixNano())
+	}
+	return h.Sum64()
+}
+
+// CacheVersion returns the generation of the cached data, incremented whenever a refresh changes it,
+// and when it last changed. The generation is 0 until the data is loaded.
+func CacheVersion() (generation uint64, modified time.Time) {
+	info := loadCacheInfo()
+	return info.generation, info.modified
+}
+
+// StartAutoRefresh loads the cache, then refreshes it every time it expires until ctx is cancelled,
+// so the server becomes ready without waiting for a request, and changes are detected and pushed
+// to listeners even when no page is requested.
+func StartAutoRefresh(ctx context.Context) {
+	ticker := time.NewTicker(cacheTTL)
+	defer ticker.Stop()
+
+	for {
+		// Each background refresh gets its own ID so its upstream requests can be traced.
+		refreshCtx := logging.WithRequestID(ctx, "refresh-"+logging.NewRequestID())
+		if err := Refresh(refreshCtx); err != nil {
+			slog.ErrorContext(refreshCtx, "Error refreshing cached data", "error", err)
 		}
-		cache = data
-		lastCacheTime = time.Now()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CacheStatus describes the cached data and its latest refresh.
+type CacheStatus struct {
+	Artists            int        `json:"artists"`
+	LastRefresh        *time.Time `json:"lastRefresh,omitempty"`
+	Age                float64    `json:"ageSeconds"`
+	TTL                float64    `json:"ttlSeconds"`
+	LastRefreshAttempt *time.Time `json:"lastRefreshAttempt,omitempty"`
+	LastRefreshError   string     `json:"lastRefreshError,omitempty"`
+}
+
+// GetCacheStatus returns the state of the cache without refreshing it, or waiting for a refresh in progress.
+func GetCacheStatus() CacheStatus {
+	info := loadCacheInfo()
+
+	status := CacheStatus{Artists: info.artists, TTL: cacheTTL.Seconds()}
+	if !info.updated.IsZero() {
+		lastRefresh := info.updated
+		status.LastRefresh = &lastRefresh
+		status.Age = time.Since(info.updated).Seconds()
+	}
+	if !info.attempt.IsZero() {
+		attempt := info.attempt
+		status.LastRefreshAttempt = &attempt
+	}
+	if info.err != nil {
+		status.LastRefreshError = info.err.Error()
+	}
+	return status
+}
+
+// Ready reports whether the cache holds data fresh enough to be served, and why not otherwise.
+// Data is considered stale once two refreshes in a row were missed.
+func Ready() (bool, string) {
+	status := GetCacheStatus()
+	switch {
+	case status.Artists == 0:
+		return false, "data not loaded yet"
+	case status.Age > 2*status.TTL:
+		return false, fmt.Sprintf("data is %.0fs old, the last refresh failed: %s", status.Age, status.LastRefreshError)
 	}
-	return cache, nil
+	return true, ""
 }
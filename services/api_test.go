@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"groopie_local/internal/testutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetCache empties the cache and its state, and restores them and the API settings when the test ends,
+// so tests do not depend on the data loaded by the ones before.
+func resetCache(t *testing.T) {
+	t.Helper()
+	baseURL, timeout, ttl := apiBaseURL, apiTimeout, cacheTTL
+	reset := func() {
+		refreshLock.Lock()
+		defer refreshLock.Unlock()
+		cacheLock.Lock()
+		cache, artistIndex, stats = nil, nil, Stats{}
+		cacheLock.Unlock()
+		cacheDigest = 0
+		cacheState.Store(nil)
+	}
+	reset()
+	t.Cleanup(func() {
+		reset()
+		ConfigureAPI(baseURL, timeout, ttl)
+	})
+}
+
+func TestCacheStatusDuringRefresh(t *testing.T) {
+	resetCache(t)
+
+	// The API holds every request until released, and reports when the refresh reached it.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	api := testutil.NewAPI(t, testutil.APIResponses)
+	api.OnRequest(func(r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+	})
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+	ConfigureAPI(api.URL, 5*time.Second, time.Minute)
+
+	refreshed := make(chan error)
+	go func() { refreshed <- Refresh(context.Background()) }()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the refresh did not reach the API")
+	}
+
+	// The status and readiness are reported while the upstream requests are pending.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if ready, _ := Ready(); ready {
+			t.Error("Ready() = true before the data is loaded")
+		}
+		if status := GetCacheStatus(); status.Artists != 0 {
+			t.Errorf("GetCacheStatus().Artists = %d before the data is loaded", status.Artists)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("GetCacheStatus() waited for the refresh")
+	}
+
+	close(release)
+	if err := <-refreshed; err != nil {
+		t.Fatalf("Refresh() = %v", err)
+	}
+	if status := GetCacheStatus(); status.Artists != 1 || status.LastRefresh == nil {
+		t.Errorf("GetCacheStatus() = %+v, want 1 artist", status)
+	}
+	if ready, reason := Ready(); !ready {
+		t.Errorf("Ready() = false (%s), want true", reason)
+	}
+	if artist, found, err := GetArtist(context.Background(), 1); err != nil || !found || artist.Artist.Name != "Queen" {
+		t.Errorf("GetArtist(1) = %v, %v, %v", artist.Artist.Name, found, err)
+	}
+
+	// The generation only changes with the data.
+	generation, _ := CacheVersion()
+	if generation == 0 {
+		t.Fatal("CacheVersion() generation = 0 after loading the data")
+	}
+	if err := Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() = %v", err)
+	}
+	if again, _ := CacheVersion(); again != generation {
+		t.Errorf("CacheVersion() generation = %d after refreshing the same data, want %d", again, generation)
+	}
+}
+
+func TestGetArtist(t *testing.T) {
+	resetCache(t)
+	api := testutil.NewAPI(t, map[string]string{
+		"artists":   `[{"id":7,"name":"Queen"},{"id":120,"name":"AC/DC"}]`,
+		"locations": `{"index":[]}`,
+		"relation":  `{"index":[{"id":7,"datesLocations":{"london-uk":["01-01-2020"]}},{"id":120,"datesLocations":{}}]}`,
+		"dates":     `{"index":[]}`,
+	})
+	ConfigureAPI(api.URL, 5*time.Second, time.Hour)
+
+	tests := []struct {
+		id    int
+		found bool
+		name  string
+	}{
+		{id: 7, found: true, name: "Queen"},
+		{id: 120, found: true, name: "AC/DC"},
+		{id: 1},
+		{id: 0},
+		{id: -7},
+	}
+	for _, tt := range tests {
+		artist, found, err := GetArtist(context.Background(), tt.id)
+		if err != nil || found != tt.found || artist.Artist.Name != tt.name {
+			t.Errorf("GetArtist(%d) = %q, %v, %v, want %q, %v", tt.id, artist.Artist.Name, found, err, tt.name, tt.found)
+		}
+	}
+	if artist, _, _ := GetArtist(context.Background(), 7); len(artist.Relations.DatesLocations["london-uk"]) != 1 {
+		t.Errorf("GetArtist(7) relations = %+v, want the concert in london", artist.Relations)
+	}
+
+	// The index follows the data when artists are removed or added.
+	api.SetResponses(map[string]string{
+		"artists":   `[{"id":120,"name":"AC/DC"},{"id":200,"name":"ABBA"}]`,
+		"locations": `{"index":[]}`,
+		"relation":  `{"index":[]}`,
+		"dates":     `{"index":[]}`,
+	})
+	if err := Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[int]string{7: "", 120: "AC/DC", 200: "ABBA"} {
+		if artist, found, _ := GetArtist(context.Background(), id); found != (want != "") || artist.Artist.Name != want {
+			t.Errorf("GetArtist(%d) after the refresh = %q, %v, want %q", id, artist.Artist.Name, found, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the API while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("upstream circuit breaker is open")
+
+// Circuit breaker states.
+const (
+	CircuitClosed   = "closed"    // Requests go through.
+	CircuitOpen     = "open"      // Requests fail fast until the cooldown ends.
+	CircuitHalfOpen = "half-open" // The cooldown ended: the next result closes or reopens the circuit.
+)
+
+// CircuitBreaker stops calling a failing upstream service for a while after too many consecutive failures,
+// so requests fail fast instead of each waiting for a timeout.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int // Consecutive failures.
+	openedAt  time.Time
+	lastError string
+}
+
+// CircuitStatus is a snapshot of a circuit breaker.
+type CircuitStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+}
+
+// NewCircuitBreaker returns a breaker opening after threshold consecutive failures for the cooldown duration.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// apiBreaker guards the requests to the Groupie Trackers API.
+var apiBreaker = NewCircuitBreaker(5, 30*time.Second)
+
+// ConfigureBreaker sets after how many consecutive failures the API circuit breaker opens, and for how long.
+// It must be called before the server starts.
+func ConfigureBreaker(threshold int, cooldown time.Duration) {
+	apiBreaker = NewCircuitBreaker(threshold, cooldown)
+}
+
+// APICircuit returns the state of the API circuit breaker.
+func APICircuit() CircuitStatus {
+	return apiBreaker.Status()
+}
+
+// stateLocked returns the current state. The caller must hold b.mu.
+func (b *CircuitBreaker) stateLocked() string {
+	switch {
+	case b.failures < b.threshold:
+		return CircuitClosed
+	case time.Since(b.openedAt) < b.cooldown:
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
+
+// Allow reports whether a request may be sent, i.e. the circuit is not open.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked() != CircuitOpen
+}
+
+// Record updates the breaker with the result of a request: a success closes the circuit, while a failure
+// opens it once the threshold is reached, or reopens it for another cooldown when it was half-open.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		b.lastError = ""
+		return
+	}
+	b.lastError = err.Error()
+	if b.stateLocked() == CircuitOpen {
+		// A request sent before the circuit opened; it does not extend the cooldown.
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// Status returns a snapshot of the breaker.
+func (b *CircuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := CircuitStatus{State: b.stateLocked(), ConsecutiveFailures: b.failures, LastError: b.lastError}
+	if status.State != CircuitClosed {
+		openedAt, retryAt := b.openedAt, b.openedAt.Add(b.cooldown)
+		status.OpenedAt, status.RetryAt = &openedAt, &retryAt
+	}
+	return status
+}
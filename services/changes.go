@@ -0,0 +1,270 @@
+package services
+
+import (
+	"groopie_local/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxDiffHistory bounds the number of refresh diffs kept in memory.
+const maxDiffHistory = 100
+
+// ConcertChange identifies a concert of an artist that appeared or disappeared between two refreshes.
+type ConcertChange struct {
+	ArtistID   int    `json:"artistId"`
+	ArtistName string `json:"artistName"`
+	Location   string `json:"location"`
+	Date       string `json:"date"`
+}
+
+// MemberChange lists the members that joined or left an artist between two refreshes.
+type MemberChange struct {
+	ArtistID   int      `json:"artistId"`
+	ArtistName string   `json:"artistName"`
+	Added      []string `json:"added"`
+	Removed    []string `json:"removed"`
+}
+
+// Diff describes what changed in the dataset between two successive refreshes of the cache.
+type Diff struct {
+	Time            time.Time       `json:"time"`
+	ArtistCount     int             `json:"artistCount"`
+	AddedArtists    []models.Artist `json:"addedArtists"`
+	RemovedArtists  []models.Artist `json:"removedArtists"`
+	MembersChanged  []MemberChange  `json:"membersChanged"`
+	AddedConcerts   []ConcertChange `json:"addedConcerts"`
+	RemovedConcerts []ConcertChange `json:"removedConcerts"`
+	// Artists is the data the refresh produced, so listeners act on the snapshot the diff describes
+	// rather than on whatever the cache holds when they run. It is not kept in the history.
+	Artists []models.ArtistFull `json:"-"`
+}
+
+// IsEmpty reports whether the diff contains no change at all.
+func (d *Diff) IsEmpty() bool {
+	return len(d.AddedArtists) == 0 && len(d.RemovedArtists) == 0 && len(d.MembersChanged) == 0 &&
+		len(d.AddedConcerts) == 0 && len(d.RemovedConcerts) == 0
+}
+
+// Event types emitted for the changes of a diff.
+const (
+	EventArtistAdded    = "artist.added"
+	EventArtistRemoved  = "artist.removed"
+	EventMembersChanged = "members.changed"
+	EventConcertAdded   = "concert.added"
+	EventConcertRemoved = "concert.removed"
+)
+
+// ChangeEvent is a single change of a diff, flattened for notifications.
+type ChangeEvent struct {
+	Type       string   `json:"type"`
+	ArtistID   int      `json:"artistId"`
+	ArtistName string   `json:"artistName"`
+	Location   string   `json:"location,omitempty"`
+	Date       string   `json:"date,omitempty"`
+	Added      []string `json:"added,omitempty"`
+	Removed    []string `json:"removed,omitempty"`
+}
+
+// Events flattens the diff into individual change events, grouped by type.
+func (d *Diff) Events() []ChangeEvent {
+	var events []ChangeEvent
+	for _, a := range d.AddedArtists {
+		events = append(events, ChangeEvent{Type: EventArtistAdded, ArtistID: a.ID, ArtistName: a.Name})
+	}
+	for _, a := range d.RemovedArtists {
+		events = append(events, ChangeEvent{Type: EventArtistRemoved, ArtistID: a.ID, ArtistName: a.Name})
+	}
+	for _, m := range d.MembersChanged {
+		events = append(events, ChangeEvent{
+			Type:       EventMembersChanged,
+			ArtistID:   m.ArtistID,
+			ArtistName: m.ArtistName,
+			Added:      m.Added,
+			Removed:    m.Removed,
+		})
+	}
+	for _, c := range d.AddedConcerts {
+		events = append(events, ChangeEvent{Type: EventConcertAdded, ArtistID: c.ArtistID, ArtistName: c.ArtistName, Location: c.Location, Date: c.Date})
+	}
+	for _, c := range d.RemovedConcerts {
+		events = append(events, ChangeEvent{Type: EventConcertRemoved, ArtistID: c.ArtistID, ArtistName: c.ArtistName, Location: c.Location, Date: c.Date})
+	}
+	return events
+}
+
+var (
+	// diffHistory stores the diffs of recent refreshes, oldest first.
+	diffHistory []Diff
+	// diffLock ensures thread-safe access to diffHistory and changeListeners.
+	diffLock sync.Mutex
+	// changeListeners are the queues of the registered listeners, fed with the diff of every refresh.
+	changeListeners []chan Diff
+)
+
+// changeQueueSize is the number of diffs a slow listener can fall behind before refreshes wait for it.
+const changeQueueSize = 16
+
+// OnChange registers a function that is called with the diff of every refresh after the first load,
+// including refreshes where nothing changed. Each listener runs in its own goroutine and receives
+// the diffs one at a time, in the order of the refreshes.
+func OnChange(fn func(Diff)) {
+	queue := make(chan Diff, changeQueueSize)
+	go func() {
+		for diff := range queue {
+			fn(diff)
+		}
+	}()
+
+	diffLock.Lock()
+	defer diffLock.Unlock()
+	changeListeners = append(changeListeners, queue)
+}
+
+// DiffData compares two snapshots of the merged data and returns the artists, members and
+// concerts that were added or removed. Results are ordered by artist ID, location and date.
+func DiffData(oldData, newData []models.ArtistFull) Diff {
+	diff := Diff{Time: time.Now(), ArtistCount: len(newData)}
+
+	oldByID := make(map[int]models.ArtistFull, len(oldData))
+	for _, a := range oldData {
+		oldByID[a.Artist.ID] = a
+	}
+	newByID := make(map[int]models.ArtistFull, len(newData))
+	for _, a := range newData {
+		newByID[a.Artist.ID] = a
+	}
+
+	for _, a := range newData {
+		old, existed := oldByID[a.Artist.ID]
+		if !existed {
+			diff.AddedArtists = append(diff.AddedArtists, a.Artist)
+		} else if added, removed := diffStrings(old.Artist.Members, a.Artist.Members); len(added) > 0 || len(removed) > 0 {
+			diff.MembersChanged = append(diff.MembersChanged, MemberChange{
+				ArtistID:   a.Artist.ID,
+				ArtistName: a.Artist.Name,
+				Added:      added,
+				Removed:    removed,
+			})
+		}
+		diff.AddedConcerts = append(diff.AddedConcerts, diffConcerts(a, old.Relations.DatesLocations)...)
+	}
+
+	for _, a := range oldData {
+		current, exists := newByID[a.Artist.ID]
+		if !exists {
+			diff.RemovedArtists = append(diff.RemovedArtists, a.Artist)
+		}
+		diff.RemovedConcerts = append(diff.RemovedConcerts, diffConcerts(a, current.Relations.DatesLocations)...)
+	}
+
+	sort.Slice(diff.AddedArtists, func(i, j int) bool {
+		return diff.AddedArtists[i].ID < diff.AddedArtists[j].ID
+	})
+	sort.Slice(diff.RemovedArtists, func(i, j int) bool {
+		return diff.RemovedArtists[i].ID < diff.RemovedArtists[j].ID
+	})
+	sort.Slice(diff.MembersChanged, func(i, j int) bool {
+		return diff.MembersChanged[i].ArtistID < diff.MembersChanged[j].ArtistID
+	})
+	sortConcertChanges(diff.AddedConcerts)
+	sortConcertChanges(diff.RemovedConcerts)
+	return diff
+}
+
+// diffConcerts returns the concerts of the artist that are missing from other.
+func diffConcerts(artist models.ArtistFull, other map[string][]string) []ConcertChange {
+	var changes []ConcertChange
+	for location, dates := range artist.Relations.DatesLocations {
+		for _, date := range dates {
+			if containsString(other[location], date) {
+				continue
+			}
+			changes = append(changes, ConcertChange{
+				ArtistID:   artist.Artist.ID,
+				ArtistName: artist.Artist.Name,
+				Location:   location,
+				Date:       date,
+			})
+		}
+	}
+	return changes
+}
+
+// diffStrings returns the values of b missing from a (added) and the values of a missing from b (removed).
+func diffStrings(a, b []string) (added, removed []string) {
+	for _, s := range b {
+		if !containsString(a, s) {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !containsString(b, s) {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// recordDiff computes the diff between the previous and the refreshed cache, appends it
+// to the bounded history if anything changed and notifies the change listeners either way,
+// handing them the refreshed data along with the diff.
+// The very first load has nothing to compare against and is not recorded.
+// A listener whose queue is full holds up the refresh until it catches up.
+func recordDiff(oldData, newData []models.ArtistFull) {
+	if len(oldData) == 0 {
+		return
+	}
+	diff := DiffData(oldData, newData)
+
+	diffLock.Lock()
+	if !diff.IsEmpty() {
+		diffHistory = append(diffHistory, diff)
+		if len(diffHistory) > maxDiffHistory {
+			diffHistory = diffHistory[len(diffHistory)-maxDiffHistory:]
+		}
+	}
+	listeners := changeListeners
+	diffLock.Unlock()
+
+	diff.Artists = newData
+	for _, queue := range listeners {
+		queue <- diff
+	}
+}
+
+// DiffHistory returns a copy of the recorded refresh diffs, newest first.
+func DiffHistory() []Diff {
+	diffLock.Lock()
+	defer diffLock.Unlock()
+
+	history := make([]Diff, len(diffHistory))
+	for i, d := range diffHistory {
+		history[len(diffHistory)-1-i] = d
+	}
+	return history
+}
+
+// sortConcertChanges orders concert changes by artist ID, location and date.
+func sortConcertChanges(changes []ConcertChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.ArtistID != b.ArtistID {
+			return a.ArtistID < b.ArtistID
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Date < b.Date
+	})
+}
+
+// containsString checks if a slice contains the specified string value.
+func containsString(slice []string, value string) bool {
+	for _, item := range slice {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"groopie_local/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// artistFull returns an artist with the given members and concerts, keyed by location.
+func artistFull(id int, name string, members []string, concerts map[string][]string) models.ArtistFull {
+	return models.ArtistFull{
+		Artist:    models.Artist{ID: id, Name: name, Members: members},
+		Relations: models.Relations{ID: id, DatesLocations: concerts},
+	}
+}
+
+func TestDiffData(t *testing.T) {
+	queen := artistFull(1, "Queen", []string{"Freddie", "Brian"}, map[string][]string{"london-uk": {"01-01-2020"}})
+	acdc := artistFull(2, "AC/DC", []string{"Angus"}, map[string][]string{"sydney-australia": {"02-02-2020"}})
+
+	tests := []struct {
+		name     string
+		old, new []models.ArtistFull
+		want     Diff
+	}{
+		{
+			name: "unchanged",
+			old:  []models.ArtistFull{queen, acdc},
+			new:  []models.ArtistFull{queen, acdc},
+			want: Diff{ArtistCount: 2},
+		},
+		{
+			name: "artist added",
+			old:  []models.ArtistFull{queen},
+			new:  []models.ArtistFull{queen, acdc},
+			want: Diff{
+				ArtistCount:   2,
+				AddedArtists:  []models.Artist{acdc.Artist},
+				AddedConcerts: []ConcertChange{{ArtistID: 2, ArtistName: "AC/DC", Location: "sydney-australia", Date: "02-02-2020"}},
+			},
+		},
+		{
+			name: "artist removed",
+			old:  []models.ArtistFull{acdc, queen},
+			new:  []models.ArtistFull{queen},
+			want: Diff{
+				ArtistCount:     1,
+				RemovedArtists:  []models.Artist{acdc.Artist},
+				RemovedConcerts: []ConcertChange{{ArtistID: 2, ArtistName: "AC/DC", Location: "sydney-australia", Date: "02-02-2020"}},
+			},
+		},
+		{
+			name: "members changed",
+			old:  []models.ArtistFull{queen},
+			new:  []models.ArtistFull{artistFull(1, "Queen", []string{"Freddie", "Roger"}, queen.Relations.DatesLocations)},
+			want: Diff{
+				ArtistCount:    1,
+				MembersChanged: []MemberChange{{ArtistID: 1, ArtistName: "Queen", Added: []string{"Roger"}, Removed: []string{"Brian"}}},
+			},
+		},
+		{
+			name: "concerts moved",
+			old:  []models.ArtistFull{queen},
+			new: []models.ArtistFull{artistFull(1, "Queen", queen.Artist.Members, map[string][]string{
+				"london-uk":    {"01-01-2020", "03-03-2020"},
+				"paris-france": {"04-04-2020"},
+			})},
+			want: Diff{
+				ArtistCount: 1,
+				AddedConcerts: []ConcertChange{
+					{ArtistID: 1, ArtistName: "Queen", Location: "london-uk", Date: "03-03-2020"},
+					{ArtistID: 1, ArtistName: "Queen", Location: "paris-france", Date: "04-04-2020"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffData(tt.old, tt.new)
+			got.Time = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffData() = %+v, want %+v", got, tt.want)
+			}
+			if got.IsEmpty() != tt.want.IsEmpty() {
+				t.Errorf("IsEmpty() = %v, want %v", got.IsEmpty(), tt.want.IsEmpty())
+			}
+		})
+	}
+}
+
+// resetChanges empties the change history and the listeners, and restores them when the test ends.
+func resetChanges(t *testing.T) {
+	t.Helper()
+	diffLock.Lock()
+	history, listeners := diffHistory, changeListeners
+	diffHistory, changeListeners = nil, nil
+	diffLock.Unlock()
+	t.Cleanup(func() {
+		diffLock.Lock()
+		defer diffLock.Unlock()
+		// Stop the goroutines of the listeners registered by the test.
+		for _, queue := range changeListeners {
+			close(queue)
+		}
+		diffHistory, changeListeners = history, listeners
+	})
+}
+
+func TestRecordDiff(t *testing.T) {
+	resetChanges(t)
+	received := make(chan Diff, 3)
+	OnChange(func(diff Diff) { received <- diff })
+
+	queen := artistFull(1, "Queen", nil, nil)
+	acdc := artistFull(2, "AC/DC", nil, nil)
+	recordDiff(nil, []models.ArtistFull{queen})
+	recordDiff([]models.ArtistFull{queen}, []models.ArtistFull{queen})
+	recordDiff([]models.ArtistFull{queen}, []models.ArtistFull{queen, acdc})
+
+	// Listeners get every diff after the first load in order, empty ones included, with the refreshed data;
+	// the history only keeps changes, without the data.
+	for i, wantArtists := range []int{1, 2} {
+		select {
+		case diff := <-received:
+			if diff.IsEmpty() != (i == 0) {
+				t.Errorf("diff %d: IsEmpty() = %v, want %v", i, diff.IsEmpty(), i == 0)
+			}
+			if len(diff.Artists) != wantArtists {
+				t.Errorf("diff %d: %d artists, want %d", i, len(diff.Artists), wantArtists)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("diff %d was not delivered", i)
+		}
+	}
+	if history := DiffHistory(); len(history) != 1 || len(history[0].AddedArtists) != 1 || history[0].Artists != nil {
+		t.Errorf("DiffHistory() = %+v, want only the diff adding an artist", history)
+	}
+}
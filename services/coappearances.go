@@ -0,0 +1,118 @@
+package services
+
+import (
+	"groopie_local/models"
+	"sort"
+	"time"
+)
+
+// nearbyDays is the largest gap, in days, between two concerts at the same location for them to be
+// considered part of the same event, e.g. the successive days of a festival.
+const nearbyDays = 3
+
+// Appearance is one artist's concert within an Event.
+type Appearance struct {
+	ArtistID   int       `json:"artistId"`
+	ArtistName string    `json:"artistName"`
+	Image      string    `json:"image"`
+	Date       time.Time `json:"date"`
+}
+
+// Event is a group of concerts by several artists at the same location on the same or nearby dates,
+// such as a festival or a shared bill.
+type Event struct {
+	Location    string       `json:"location"`
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	Appearances []Appearance `json:"appearances"`
+}
+
+// Place returns the event's location in a human-readable form, e.g. "north carolina, usa".
+func (e Event) Place() string {
+	return LocationQuery(e.Location)
+}
+
+// SameDay reports whether every artist of the event played on the same date, i.e. a shared bill.
+func (e Event) SameDay() bool {
+	return e.Start.Equal(e.End)
+}
+
+// HasArtist reports whether the artist with the given ID played at the event.
+func (e Event) HasArtist(artistID int) bool {
+	for _, appearance := range e.Appearances {
+		if appearance.ArtistID == artistID {
+			return true
+		}
+	}
+	return false
+}
+
+// artistCount returns the number of distinct artists that played at the event.
+func (e Event) artistCount() int {
+	seen := make(map[int]bool)
+	for _, appearance := range e.Appearances {
+		seen[appearance.ArtistID] = true
+	}
+	return len(seen)
+}
+
+// FindEvents groups the concerts of every artist by location and keeps the groups of concerts no more than
+// nearbyDays apart that involve at least two artists. Events are ordered by start date, then location.
+func FindEvents(artists []models.ArtistFull) []Event {
+	byLocation := make(map[string][]Appearance)
+	for i := range artists {
+		artist := &artists[i]
+		for _, concert := range artist.Relations.Concerts() {
+			byLocation[concert.Location] = append(byLocation[concert.Location], Appearance{
+				ArtistID:   artist.Artist.ID,
+				ArtistName: artist.Artist.Name,
+				Image:      artist.Artist.Image,
+				Date:       concert.Date,
+			})
+		}
+	}
+
+	var events []Event
+	for location, appearances := range byLocation {
+		sort.Slice(appearances, func(i, j int) bool {
+			if appearances[i].Date.Equal(appearances[j].Date) {
+				return appearances[i].ArtistName < appearances[j].ArtistName
+			}
+			return appearances[i].Date.Before(appearances[j].Date)
+		})
+
+		var current *Event
+		for _, appearance := range appearances {
+			if current == nil || appearance.Date.Sub(current.End) > nearbyDays*24*time.Hour {
+				if current != nil && current.artistCount() > 1 {
+					events = append(events, *current)
+				}
+				current = &Event{Location: location, Start: appearance.Date}
+			}
+			current.End = appearance.Date
+			current.Appearances = append(current.Appearances, appearance)
+		}
+		if current != nil && current.artistCount() > 1 {
+			events = append(events, *current)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Start.Equal(events[j].Start) {
+			return events[i].Location < events[j].Location
+		}
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events
+}
+
+// ArtistEvents returns the events the artist with the given ID played at.
+func ArtistEvents(events []Event, artistID int) []Event {
+	var result []Event
+	for _, event := range events {
+		if event.HasArtist(artistID) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
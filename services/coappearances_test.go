@@ -0,0 +1,110 @@
+package services
+
+import (
+	"groopie_local/models"
+	"slices"
+	"testing"
+)
+
+// eventSummary lists the event's location, start and end dates, then its artists in order.
+func eventSummary(e Event) []string {
+	summary := []string{e.Location, e.Start.Format("02-01-2006"), e.End.Format("02-01-2006")}
+	for _, appearance := range e.Appearances {
+		summary = append(summary, appearance.ArtistName)
+	}
+	return summary
+}
+
+func TestFindEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		artists []models.ArtistFull
+		want    [][]string // eventSummary of each event, in order.
+	}{
+		{
+			name: "shared bill",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", nil, map[string][]string{"london-uk": {"01-01-2020"}}),
+				artistFull(2, "AC/DC", nil, map[string][]string{"london-uk": {"01-01-2020"}}),
+			},
+			want: [][]string{{"london-uk", "01-01-2020", "01-01-2020", "AC/DC", "Queen"}},
+		},
+		{
+			name: "festival over nearby days",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", nil, map[string][]string{"leeds-uk": {"01-08-2020"}}),
+				artistFull(2, "AC/DC", nil, map[string][]string{"leeds-uk": {"03-08-2020"}}),
+				artistFull(3, "ABBA", nil, map[string][]string{"leeds-uk": {"06-08-2020"}}),
+			},
+			want: [][]string{{"leeds-uk", "01-08-2020", "06-08-2020", "Queen", "AC/DC", "ABBA"}},
+		},
+		{
+			name: "too far apart",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", nil, map[string][]string{"london-uk": {"01-01-2020"}}),
+				artistFull(2, "AC/DC", nil, map[string][]string{"london-uk": {"05-01-2020"}}),
+			},
+		},
+		{
+			name: "one artist on consecutive days",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", nil, map[string][]string{"london-uk": {"01-01-2020", "02-01-2020"}}),
+			},
+		},
+		{
+			name: "ordered by start then location",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", nil, map[string][]string{"paris-france": {"01-01-2020"}, "london-uk": {"01-01-2020"}, "berlin-germany": {"01-01-2019"}}),
+				artistFull(2, "AC/DC", nil, map[string][]string{"paris-france": {"01-01-2020"}, "london-uk": {"01-01-2020"}, "berlin-germany": {"02-01-2019"}}),
+			},
+			want: [][]string{
+				{"berlin-germany", "01-01-2019", "02-01-2019", "Queen", "AC/DC"},
+				{"london-uk", "01-01-2020", "01-01-2020", "AC/DC", "Queen"},
+				{"paris-france", "01-01-2020", "01-01-2020", "AC/DC", "Queen"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := FindEvents(tt.artists)
+			if len(events) != len(tt.want) {
+				t.Fatalf("FindEvents() returned %d events, want %d: %+v", len(events), len(tt.want), events)
+			}
+			for i, event := range events {
+				if got := eventSummary(event); !slices.Equal(got, tt.want[i]) {
+					t.Errorf("event %d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestArtistEvents(t *testing.T) {
+	events := FindEvents([]models.ArtistFull{
+		artistFull(1, "Queen", nil, map[string][]string{"london-uk": {"01-01-2020"}, "paris-france": {"01-02-2020"}}),
+		artistFull(2, "AC/DC", nil, map[string][]string{"london-uk": {"01-01-2020"}}),
+		artistFull(3, "ABBA", nil, map[string][]string{"paris-france": {"02-02-2020"}}),
+	})
+
+	tests := []struct {
+		artistID int
+		want     []string // Locations of the artist's events.
+	}{
+		{artistID: 1, want: []string{"london-uk", "paris-france"}},
+		{artistID: 2, want: []string{"london-uk"}},
+		{artistID: 3, want: []string{"paris-france"}},
+		{artistID: 4},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, event := range ArtistEvents(events, tt.artistID) {
+			got = append(got, event.Location)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ArtistEvents(%d) = %v, want %v", tt.artistID, got, tt.want)
+		}
+	}
+	if !events[0].SameDay() || events[1].SameDay() {
+		t.Errorf("SameDay() = %v, %v, want true for the shared bill and false for the two-day event", events[0].SameDay(), events[1].SameDay())
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"groopie_local/models"
+	"sort"
+	"time"
+)
+
+// Bounds of the number of artists that can be compared at once.
+const (
+	MinCompared = 2
+	MaxCompared = 4
+)
+
+// ErrCompareCount is returned when too few or too many artists are compared.
+var ErrCompareCount = fmt.Errorf("select between %d and %d different artists to compare", MinCompared, MaxCompared)
+
+// ErrArtistNotFound is returned when a compared artist does not exist.
+var ErrArtistNotFound = errors.New("artist not found")
+
+// ComparedArtist is one column of a comparison.
+type ComparedArtist struct {
+	Artist         models.Artist `json:"artist"`
+	FirstAlbumYear int           `json:"firstAlbumYear"`
+	Concerts       int           `json:"concerts"`
+	Locations      int           `json:"locations"`
+	Countries      int           `json:"countries"`
+	FirstConcert   time.Time     `json:"firstConcert"`
+	LastConcert    time.Time     `json:"lastConcert"`
+}
+
+// LocationShare is a location with the compared artists that played there.
+type LocationShare struct {
+	Location  string `json:"location"`
+	ArtistIDs []int  `json:"artistIds"`
+	// PlayedBy tells, for each compared artist in order, whether it played there.
+	PlayedBy []bool `json:"-"`
+}
+
+// Common reports whether several of the compared artists played at the location.
+func (l LocationShare) Common() bool {
+	return len(l.ArtistIDs) > 1
+}
+
+// DateShare is a date on which several of the compared artists performed.
+type DateShare struct {
+	Date time.Time `json:"date"`
+	// Concerts are the concerts of the compared artists on that date.
+	Concerts []PlaceConcert `json:"concerts"`
+	// SameLocation is true when at least two of them played at the same location.
+	SameLocation bool `json:"sameLocation"`
+}
+
+// Comparison compares artists side by side.
+type Comparison struct {
+	Artists []ComparedArtist `json:"artists"`
+	// Locations lists every location played by one of the artists, the common ones first.
+	Locations []LocationShare `json:"locations"`
+	// SharedByAll lists the locations played by every compared artist.
+	SharedByAll      []string    `json:"sharedByAll"`
+	OverlappingDates []DateShare `json:"overlappingDates"`
+}
+
+// Compare builds the comparison of the artists with the given IDs, in the given order.
+func Compare(artists []models.ArtistFull, ids []int) (Comparison, error) {
+	ids = uniqueInts(ids)
+	if len(ids) < MinCompared || len(ids) > MaxCompared {
+		return Comparison{}, ErrCompareCount
+	}
+
+	byID := make(map[int]*models.ArtistFull, len(artists))
+	for i := range artists {
+		byID[artists[i].Artist.ID] = &artists[i]
+	}
+
+	comparison := Comparison{SharedByAll: []string{}, OverlappingDates: []DateShare{}}
+	locations := make(map[string]*LocationShare)
+	byDate := make(map[string][]PlaceConcert)
+
+	for column, id := range ids {
+		artist, ok := byID[id]
+		if !ok {
+			return Comparison{}, fmt.Errorf("%w: %d", ErrArtistNotFound, id)
+		}
+
+		summary := TourSummary(*artist)
+		compared := ComparedArtist{
+			Artist:    artist.Artist,
+			Concerts:  summary.Concerts,
+			Locations: len(artist.Relations.DatesLocations),
+			Countries: len(summary.Countries),
+		}
+		if album, err := time.Parse(models.ConcertDateLayout, artist.Artist.FirstAlbum); err == nil {
+			compared.FirstAlbumYear = album.Year()
+		}
+
+		concerts := artist.Relations.Concerts()
+		if len(concerts) > 0 {
+			compared.FirstConcert = concerts[0].Date
+			compared.LastConcert = concerts[len(concerts)-1].Date
+		}
+		for _, concert := range concerts {
+			share, ok := locations[concert.Location]
+			if !ok {
+				share = &LocationShare{Location: concert.Location, PlayedBy: make([]bool, len(ids))}
+				locations[concert.Location] = share
+			}
+			if !share.PlayedBy[column] {
+				share.PlayedBy[column] = true
+				share.ArtistIDs = append(share.ArtistIDs, id)
+			}
+
+			key := concert.Date.Format(time.DateOnly)
+			byDate[key] = append(byDate[key], PlaceConcert{Concert: concert, ArtistID: id, ArtistName: artist.Artist.Name})
+		}
+		comparison.Artists = append(comparison.Artists, compared)
+	}
+
+	for _, share := range locations {
+		comparison.Locations = append(comparison.Locations, *share)
+		if len(share.ArtistIDs) == len(ids) {
+			comparison.SharedByAll = append(comparison.SharedByAll, share.Location)
+		}
+	}
+	sort.Slice(comparison.Locations, func(i, j int) bool {
+		a, b := comparison.Locations[i], comparison.Locations[j]
+		if len(a.ArtistIDs) != len(b.ArtistIDs) {
+			return len(a.ArtistIDs) > len(b.ArtistIDs)
+		}
+		return a.Location < b.Location
+	})
+	sort.Strings(comparison.SharedByAll)
+
+	for _, concerts := range byDate {
+		share := DateShare{Date: concerts[0].Date, Concerts: concerts}
+		artistsOnDate := make(map[int]bool)
+		locationsOnDate := make(map[string]int)
+		for _, concert := range concerts {
+			artistsOnDate[concert.ArtistID] = true
+			locationsOnDate[concert.Location]++
+			if locationsOnDate[concert.Location] > 1 {
+				share.SameLocation = true
+			}
+		}
+		if len(artistsOnDate) > 1 {
+			comparison.OverlappingDates = append(comparison.OverlappingDates, share)
+		}
+	}
+	sort.Slice(comparison.OverlappingDates, func(i, j int) bool {
+		return comparison.OverlappingDates[i].Date.Before(comparison.OverlappingDates[j].Date)
+	})
+	return comparison, nil
+}
+
+// uniqueInts returns the values without duplicates, keeping their first occurrence.
+func uniqueInts(values []int) []int {
+	var unique []int
+	seen := make(map[int]bool)
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"groopie_local/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	queen := artistFull(1, "Queen", nil, map[string][]string{
+		"london-uk":    {"01-01-2020", "03-01-2020"},
+		"paris-france": {"02-01-2020"},
+	})
+	artists := []models.ArtistFull{
+		queen,
+		artistFull(2, "AC/DC", nil, map[string][]string{"london-uk": {"01-01-2020"}, "sydney-australia": {"05-01-2020"}}),
+		artistFull(3, "ABBA", nil, map[string][]string{"london-uk": {"06-01-2020"}, "berlin-germany": {"02-01-2020"}}),
+	}
+
+	tests := []struct {
+		name        string
+		ids         []int
+		err         error
+		artists     []string
+		locations   []string // Locations in order, with the IDs of the artists that played there.
+		sharedByAll []string
+		dates       []string // Overlapping dates, "*" marking a shared location.
+	}{
+		{
+			name:        "two artists",
+			ids:         []int{1, 2},
+			artists:     []string{"Queen", "AC/DC"},
+			locations:   []string{"london-uk [1 2]", "paris-france [1]", "sydney-australia [2]"},
+			sharedByAll: []string{"london-uk"},
+			dates:       []string{"2020-01-01*"},
+		},
+		{
+			name:        "in the given order without duplicates",
+			ids:         []int{3, 1, 3},
+			artists:     []string{"ABBA", "Queen"},
+			locations:   []string{"london-uk [3 1]", "berlin-germany [3]", "paris-france [1]"},
+			sharedByAll: []string{"london-uk"},
+			dates:       []string{"2020-01-02"},
+		},
+		{
+			name:        "three artists",
+			ids:         []int{1, 2, 3},
+			artists:     []string{"Queen", "AC/DC", "ABBA"},
+			locations:   []string{"london-uk [1 2 3]", "berlin-germany [3]", "paris-france [1]", "sydney-australia [2]"},
+			sharedByAll: []string{"london-uk"},
+			dates:       []string{"2020-01-01*", "2020-01-02"},
+		},
+		{name: "one artist", ids: []int{1}, err: ErrCompareCount},
+		{name: "same artist twice", ids: []int{1, 1}, err: ErrCompareCount},
+		{name: "too many", ids: []int{1, 2, 3, 4, 5}, err: ErrCompareCount},
+		{name: "unknown artist", ids: []int{1, 9}, err: ErrArtistNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison, err := Compare(artists, tt.ids)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Compare(%v) error = %v, want %v", tt.ids, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			var names, locations, dates []string
+			for _, artist := range comparison.Artists {
+				names = append(names, artist.Artist.Name)
+			}
+			for _, share := range comparison.Locations {
+				locations = append(locations, share.Location+" "+fmt.Sprint(share.ArtistIDs))
+			}
+			for _, share := range comparison.OverlappingDates {
+				date := share.Date.Format(time.DateOnly)
+				if share.SameLocation {
+					date += "*"
+				}
+				dates = append(dates, date)
+			}
+			if !slices.Equal(names, tt.artists) {
+				t.Errorf("artists = %v, want %v", names, tt.artists)
+			}
+			if !slices.Equal(locations, tt.locations) {
+				t.Errorf("locations = %v, want %v", locations, tt.locations)
+			}
+			if !slices.Equal(comparison.SharedByAll, tt.sharedByAll) {
+				t.Errorf("shared by all = %v, want %v", comparison.SharedByAll, tt.sharedByAll)
+			}
+			if !slices.Equal(dates, tt.dates) {
+				t.Errorf("overlapping dates = %v, want %v", dates, tt.dates)
+			}
+		})
+	}
+}
+
+func TestComparedArtist(t *testing.T) {
+	queen := artistFull(1, "Queen", nil, map[string][]string{
+		"london-uk":    {"03-01-2020", "01-01-2020"},
+		"leeds-uk":     {"02-01-2020"},
+		"paris-france": {"04-01-2020"},
+	})
+	queen.Artist.FirstAlbum = "13-07-1973"
+	comparison, err := Compare([]models.ArtistFull{queen, artistFull(2, "AC/DC", nil, nil)}, []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := comparison.Artists[0]
+	if got.FirstAlbumYear != 1973 || got.Concerts != 4 || got.Locations != 3 || got.Countries != 2 {
+		t.Errorf("Queen: first album %d, %d concerts in %d locations and %d countries, want 1973, 4, 3 and 2",
+			got.FirstAlbumYear, got.Concerts, got.Locations, got.Countries)
+	}
+	if first, last := got.FirstConcert.Format(time.DateOnly), got.LastConcert.Format(time.DateOnly); first != "2020-01-01" || last != "2020-01-04" {
+		t.Errorf("Queen toured from %s to %s, want 2020-01-01 to 2020-01-04", first, last)
+	}
+	if acdc := comparison.Artists[1]; acdc.Concerts != 0 || !acdc.FirstConcert.IsZero() || acdc.FirstAlbumYear != 0 {
+		t.Errorf("AC/DC without concerts nor album = %+v", acdc)
+	}
+}
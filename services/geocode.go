@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"groopie_local/models"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// nominatimURL is the OpenStreetMap search endpoint used to resolve location names, the same one geolocation.js uses.
+	nominatimURL = "https://nominatim.openstreetmap.org/search"
+	// geocodeInterval is the minimum delay between two Nominatim requests, as required by its usage policy.
+	geocodeInterval = time.Second
+	// geocodeTimeout bounds each Nominatim request.
+	geocodeTimeout = 10 * time.Second
+	// geocodeRetry is how long a location that could not be geocoded is left alone before it is looked up again.
+	geocodeRetry = time.Hour
+
+	// geocodeCache stores resolved coordinates by location name, so each location is only looked up once.
+	geocodeCache = make(map[string]models.Coordinates)
+	// geocodeFailures stores the failed lookups by location name, so unknown places are not looked up again and again.
+	geocodeFailures = make(map[string]geocodeFailure)
+	// geocodeQueue lists the locations waiting for the background geocoder, and geocodeQueued holds the same locations.
+	geocodeQueue  []string
+	geocodeQueued = make(map[string]bool)
+	// nextGeocodeTime is the earliest time the next Nominatim request may be sent, to respect the rate limit.
+	nextGeocodeTime time.Time
+	// geocodeLock guards the variables above. It is never held while waiting or during a request.
+	geocodeLock sync.Mutex
+	// geocodeWake tells the background geocoder that locations were queued.
+	geocodeWake = make(chan struct{}, 1)
+)
+
+// geocodeFailure is a failed lookup and when it happened.
+type geocodeFailure struct {
+	at  time.Time
+	err error
+}
+
+// ConfigureGeocoding sets the Nominatim endpoint, the minimum delay between two of its requests and their timeout.
+// It must be called before the server starts.
+func ConfigureGeocoding(endpoint string, interval, timeout time.Duration) {
+	nominatimURL = endpoint
+	geocodeInterval = interval
+	geocodeTimeout = timeout
+}
+
+// LocationQuery converts an API location such as "north_carolina-usa" into a
+// human-readable search query such as "north carolina, usa".
+func LocationQuery(location string) string {
+	city, country, _ := strings.Cut(location, "-")
+	query := strings.ReplaceAll(city, "_", " ")
+	if country != "" {
+		query += ", " + strings.ReplaceAll(country, "_", " ")
+	}
+	return query
+}
+
+// CachedCoordinates returns the coordinates of an API location name if they are already known.
+// It never queries Nominatim, so it can be used while serving requests: unknown locations are queued
+// for the background geocoder instead, and show up once it resolved them.
+func CachedCoordinates(location string) (models.Coordinates, bool) {
+	geocodeLock.Lock()
+	coords, ok := geocodeCache[location]
+	geocodeLock.Unlock()
+	if !ok {
+		QueueGeocoding(location)
+	}
+	return coords, ok
+}
+
+// QueueGeocoding queues locations for the background geocoder, unless they are resolved, already queued,
+// or failed less than an hour ago.
+func QueueGeocoding(locations ...string) {
+	geocodeLock.Lock()
+	queued := false
+	for _, location := range locations {
+		if geocodeQueued[location] || !needsGeocodingLocked(location) {
+			continue
+		}
+		geocodeQueue = append(geocodeQueue, location)
+		geocodeQueued[location] = true
+		queued = true
+	}
+	geocodeLock.Unlock()
+
+	if queued {
+		select {
+		case geocodeWake <- struct{}{}:
+		default: // The geocoder is already awake.
+		}
+	}
+}
+
+// needsGeocodingLocked reports whether the location is neither resolved nor failed recently.
+// The caller must hold geocodeLock.
+func needsGeocodingLocked(location string) bool {
+	if _, ok := geocodeCache[location]; ok {
+		return false
+	}
+	failure, failed := geocodeFailures[location]
+	return !failed || time.Since(failure.at) > geocodeRetry
+}
+
+// StartGeocoding resolves the queued locations one at a time, as fast as the rate limit allows,
+// until ctx is cancelled. It runs in the background so requests never wait for Nominatim.
+func StartGeocoding(ctx context.Context) {
+	for {
+		geocodeLock.Lock()
+		var location string
+		if len(geocodeQueue) > 0 {
+			location = geocodeQueue[0]
+			geocodeQueue = geocodeQueue[1:]
+			delete(geocodeQueued, location)
+		}
+		geocodeLock.Unlock()
+
+		if location == "" {
+			select {
+			case <-ctx.Done():
+				return
+			case <-geocodeWake:
+			}
+			continue
+		}
+		if _, err := Geocode(ctx, location); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.WarnContext(ctx, "Error geocoding location", "error", err)
+		}
+	}
+}
+
+// Geocode returns the coordinates of an API location name.
+// Results and failures are cached in memory; other locations are resolved through Nominatim once the rate limit
+// allows it, waiting without holding any lock so lookups of cached locations never wait for another one.
+func Geocode(ctx context.Context, location string) (models.Coordinates, error) {
+	geocodeLock.Lock()
+	if coords, ok := geocodeCache[location]; ok {
+		geocodeLock.Unlock()
+		return coords, nil
+	}
+	if !needsGeocodingLocked(location) {
+		err := geocodeFailures[location].err
+		geocodeLock.Unlock()
+		return models.Coordinates{}, fmt.Errorf("geocode %s: %w", location, err)
+	}
+	// Reserve the next request slot, then wait for it outside the lock.
+	slot := time.Now()
+	if nextGeocodeTime.After(slot) {
+		slot = nextGeocodeTime
+	}
+	nextGeocodeTime = slot.Add(geocodeInterval)
+	geocodeLock.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return models.Coordinates{}, ctx.Err()
+	case <-timer.C:
+	}
+
+	coords, err := fetchCoordinates(ctx, LocationQuery(location))
+
+	geocodeLock.Lock()
+	defer geocodeLock.Unlock()
+	if err != nil {
+		// A cancelled lookup says nothing about the location.
+		if ctx.Err() == nil {
+			geocodeFailures[location] = geocodeFailure{at: time.Now(), err: err}
+		}
+		return models.Coordinates{}, fmt.Errorf("geocode %s: %w", location, err)
+	}
+	geocodeCache[location] = coords
+	delete(geocodeFailures, location)
+	return coords, nil
+}
+
+// queueLocations queues every concert location of the artists for the background geocoder.
+func queueLocations(artists []models.ArtistFull) {
+	var locations []string
+	for _, artist := range artists {
+		for location := range artist.Relations.DatesLocations {
+			locations = append(locations, location)
+		}
+	}
+	sort.Strings(locations)
+	QueueGeocoding(locations...)
+}
+
+// fetchCoordinates queries Nominatim for the given search string and returns the first result.
+// The outcome and duration of the request are recorded in the upstream metrics.
+func fetchCoordinates(ctx context.Context, query string) (models.Coordinates, error) {
+	start := time.Now()
+	outcome := "network_error"
+	defer func() {
+		observeUpstream("nominatim", outcome, start)
+		slog.DebugContext(ctx, "Upstream request", "endpoint", "nominatim", "outcome", outcome, "duration", time.Since(start))
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+	defer cancel()
+
+	reqURL := nominatimURL + "?" + url.Values{"format": {"json"}, "limit": {"1"}, "q": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return models.Coordinates{}, err
+	}
+	// Nominatim rejects requests without an identifying User-Agent.
+	req.Header.Set("User-Agent", "groupie-tracker")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.Coordinates{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		outcome = statusOutcome(resp.StatusCode)
+		return models.Coordinates{}, fmt.Errorf("error: received status code %d from %s", resp.StatusCode, nominatimURL)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		outcome = "decode_error"
+		return models.Coordinates{}, err
+	}
+	outcome = "success"
+	if len(results) == 0 {
+		return models.Coordinates{}, fmt.Errorf("no results for %q", query)
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return models.Coordinates{}, err
+	}
+	lon, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return models.Coordinates{}, err
+	}
+	return models.Coordinates{Lat: lat, Lon: lon}, nil
+}
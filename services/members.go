@@ -0,0 +1,73 @@
+package services
+
+import (
+	"groopie_local/models"
+	"sort"
+)
+
+// Members returns every member of the artists, identified by the slug of their name, with the artists
+// they play in. Members are ordered by name.
+func Members(artists []models.ArtistFull) []models.Member {
+	index := make(map[string]*models.Member)
+	var members []*models.Member
+	for i := range artists {
+		artist := artists[i].Artist
+		for _, name := range artist.Members {
+			slug := models.MemberSlug(name)
+			if slug == "" {
+				continue
+			}
+			member, ok := index[slug]
+			if !ok {
+				member = &models.Member{Name: name, Slug: slug}
+				index[slug] = member
+				members = append(members, member)
+			}
+			// Skip names listed twice in the same artist.
+			if n := len(member.Artists); n > 0 && member.Artists[n-1].ID == artist.ID {
+				continue
+			}
+			member.Artists = append(member.Artists, artist)
+		}
+	}
+
+	result := make([]models.Member, 0, len(members))
+	for _, member := range members {
+		sort.Slice(member.Artists, func(i, j int) bool { return member.Artists[i].Name < member.Artists[j].Name })
+		result = append(result, *member)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// FindMember returns the member with the given slug, or false if no artist has such a member.
+func FindMember(artists []models.ArtistFull, slug string) (models.Member, bool) {
+	for _, member := range Members(artists) {
+		if member.Slug == slug {
+			return member, true
+		}
+	}
+	return models.Member{}, false
+}
+
+// ArtistMembers returns the members of the artist with the given ID, in the artist's order,
+// each with every artist they play in.
+func ArtistMembers(artists []models.ArtistFull, artistID int) []models.Member {
+	bySlug := make(map[string]models.Member)
+	for _, member := range Members(artists) {
+		bySlug[member.Slug] = member
+	}
+
+	var result []models.Member
+	for i := range artists {
+		if artists[i].Artist.ID != artistID {
+			continue
+		}
+		for _, name := range artists[i].Artist.Members {
+			if member, ok := bySlug[models.MemberSlug(name)]; ok {
+				result = append(result, member)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"groopie_local/models"
+	"slices"
+	"testing"
+)
+
+// memberSummary lists the member's slug followed by the names of their artists.
+func memberSummary(m models.Member) []string {
+	summary := []string{m.Slug}
+	for _, artist := range m.Artists {
+		summary = append(summary, artist.Name)
+	}
+	return summary
+}
+
+func TestMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		artists []models.ArtistFull
+		want    [][]string // memberSummary of each member, in order.
+	}{
+		{
+			name: "ordered by name",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", []string{"Freddie Mercury", "Brian May"}, nil),
+			},
+			want: [][]string{{"brian-may", "Queen"}, {"freddie-mercury", "Queen"}},
+		},
+		{
+			name: "same person in several artists",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queens of the Stone Age", []string{"Dave Grohl", "Josh Homme"}, nil),
+				artistFull(2, "Foo Fighters", []string{"Dave Grohl"}, nil),
+				artistFull(3, "Nirvana", []string{"dave grohl"}, nil),
+			},
+			want: [][]string{{"dave-grohl", "Foo Fighters", "Nirvana", "Queens of the Stone Age"}, {"josh-homme", "Queens of the Stone Age"}},
+		},
+		{
+			name: "listed twice in an artist",
+			artists: []models.ArtistFull{
+				artistFull(1, "Queen", []string{"Freddie Mercury", "Freddie  Mercury"}, nil),
+			},
+			want: [][]string{{"freddie-mercury", "Queen"}},
+		},
+		{
+			name: "names without a slug",
+			artists: []models.ArtistFull{
+				artistFull(1, "Mystery", []string{"???", ""}, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := Members(tt.artists)
+			if len(members) != len(tt.want) {
+				t.Fatalf("Members() returned %d members, want %d: %+v", len(members), len(tt.want), members)
+			}
+			for i, member := range members {
+				if got := memberSummary(member); !slices.Equal(got, tt.want[i]) {
+					t.Errorf("member %d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindMember(t *testing.T) {
+	artists := []models.ArtistFull{
+		artistFull(1, "Queen", []string{"Freddie Mercury"}, nil),
+		artistFull(2, "Freddie Mercury & Montserrat Caballé", []string{"Freddie Mercury", "Montserrat Caballé"}, nil),
+	}
+
+	tests := []struct {
+		slug    string
+		found   bool
+		name    string
+		artists int
+	}{
+		{slug: "freddie-mercury", found: true, name: "Freddie Mercury", artists: 2},
+		{slug: "montserrat-caballé", found: true, name: "Montserrat Caballé", artists: 1},
+		{slug: "Freddie-Mercury"},
+		{slug: "brian-may"},
+		{slug: ""},
+	}
+	for _, tt := range tests {
+		member, found := FindMember(artists, tt.slug)
+		if found != tt.found || member.Name != tt.name || len(member.Artists) != tt.artists {
+			t.Errorf("FindMember(%q) = %q in %d artists, %v, want %q in %d, %v",
+				tt.slug, member.Name, len(member.Artists), found, tt.name, tt.artists, tt.found)
+		}
+	}
+}
+
+func TestArtistMembers(t *testing.T) {
+	artists := []models.ArtistFull{
+		artistFull(1, "Queens of the Stone Age", []string{"Josh Homme", "Dave Grohl"}, nil),
+		artistFull(2, "Foo Fighters", []string{"Dave Grohl"}, nil),
+	}
+
+	members := ArtistMembers(artists, 1)
+	if len(members) != 2 || members[0].Name != "Josh Homme" || members[1].Name != "Dave Grohl" {
+		t.Fatalf("ArtistMembers(1) = %+v, want Josh Homme then Dave Grohl", members)
+	}
+	if others := members[1].InOtherArtists(1); len(others) != 1 || others[0].Name != "Foo Fighters" {
+		t.Errorf("Dave Grohl's other artists = %+v, want Foo Fighters", others)
+	}
+	if members := ArtistMembers(artists, 3); len(members) != 0 {
+		t.Errorf("ArtistMembers of an unknown artist = %+v, want none", members)
+	}
+}
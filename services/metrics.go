@@ -0,0 +1,60 @@
+package services
+
+import (
+	"groopie_local/metrics"
+	"strconv"
+	"time"
+)
+
+var (
+	// cacheRequests counts the reads of the cached data, by whether they were served from the cache.
+	cacheRequests = metrics.NewCounter("groupie_cache_requests_total",
+		"Reads of the cached API data, by result (hit or miss).", "result")
+	// cacheRefreshes counts the refreshes of the cache, by result.
+	cacheRefreshes = metrics.NewCounter("groupie_cache_refreshes_total",
+		"Refreshes of the cached API data, by result (success or error).", "result")
+	// cacheRefreshDuration measures how long fetching and merging the API data takes.
+	cacheRefreshDuration = metrics.NewHistogram("groupie_cache_refresh_duration_seconds",
+		"Duration of the refreshes of the cached API data.", metrics.DefaultBuckets)
+	// upstreamRequests counts the requests to the upstream services, by endpoint and outcome.
+	upstreamRequests = metrics.NewCounter("groupie_upstream_requests_total",
+		"Requests to the upstream API and geocoding service, by endpoint and outcome "+
+			"(success, network_error, http_<status>, decode_error or circuit_open).", "endpoint", "outcome")
+	// upstreamDuration measures the requests to the upstream services, by endpoint.
+	upstreamDuration = metrics.NewHistogram("groupie_upstream_request_duration_seconds",
+		"Duration of the requests to the upstream API and geocoding service.", metrics.DefaultBuckets, "endpoint")
+)
+
+func init() {
+	metrics.NewGaugeFunc("groupie_cache_artists", "Number of artists in the cached API data.", func() float64 {
+		return float64(loadCacheInfo().artists)
+	})
+	metrics.NewGaugeFunc("groupie_upstream_circuit_state",
+		"State of the API circuit breaker: 0 closed, 1 half-open, 2 open.", func() float64 {
+			switch APICircuit().State {
+			case CircuitHalfOpen:
+				return 1
+			case CircuitOpen:
+				return 2
+			}
+			return 0
+		})
+	metrics.NewGaugeFunc("groupie_cache_age_seconds", "Time since the cached API data was last refreshed.", func() float64 {
+		info := loadCacheInfo()
+		if info.updated.IsZero() {
+			return 0
+		}
+		return time.Since(info.updated).Seconds()
+	})
+}
+
+// observeUpstream records the outcome and duration of a request to an upstream endpoint started at start.
+func observeUpstream(endpoint, outcome string, start time.Time) {
+	upstreamRequests.Inc(endpoint, outcome)
+	upstreamDuration.Observe(time.Since(start).Seconds(), endpoint)
+}
+
+// statusOutcome is the upstream outcome of an unexpected HTTP status, e.g. "http_503".
+func statusOutcome(status int) string {
+	return "http_" + strconv.Itoa(status)
+}
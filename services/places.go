@@ -0,0 +1,87 @@
+package services
+
+import (
+	"groopie_local/models"
+	"sort"
+	"strings"
+)
+
+// PlaceConcert is a concert listed on a location or country page.
+type PlaceConcert struct {
+	models.Concert
+	ArtistID   int    `json:"artistId"`
+	ArtistName string `json:"artistName"`
+}
+
+// Place gathers every artist and concert at a location, or in a country.
+type Place struct {
+	Slug      string          `json:"slug"`    // API location ("london-uk") or country code ("uk").
+	Name      string          `json:"name"`    // Human-readable name ("london, uk" or "uk").
+	Country   string          `json:"country"` // Country code of the place.
+	Locations []string        `json:"locations"`
+	Artists   []models.Artist `json:"artists"`
+	Concerts  []PlaceConcert  `json:"concerts"`
+}
+
+// CountryCode returns the country part of an API location as used in country URLs,
+// e.g. "new_zealand" for "auckland-new_zealand".
+func CountryCode(location string) string {
+	_, country, _ := strings.Cut(location, "-")
+	return country
+}
+
+// CountryName returns the readable name of a country code, e.g. "new zealand" for "new_zealand".
+func CountryName(code string) string {
+	return strings.ReplaceAll(code, "_", " ")
+}
+
+// FindLocation returns the artists and concerts at the given API location, or false if nobody played there.
+func FindLocation(artists []models.ArtistFull, slug string) (Place, bool) {
+	place := collectPlace(artists, func(location string) bool { return location == slug })
+	place.Slug = slug
+	place.Name = LocationQuery(slug)
+	place.Country = CountryCode(slug)
+	return place, len(place.Concerts) > 0
+}
+
+// FindCountry returns the artists, locations and concerts in the given country, or false if nobody played there.
+func FindCountry(artists []models.ArtistFull, code string) (Place, bool) {
+	place := collectPlace(artists, func(location string) bool { return CountryCode(location) == code })
+	place.Slug = code
+	place.Name = CountryName(code)
+	place.Country = code
+	return place, len(place.Concerts) > 0
+}
+
+// collectPlace gathers the concerts at the locations accepted by match, in chronological order,
+// with the distinct locations and the artists ordered by name.
+func collectPlace(artists []models.ArtistFull, match func(location string) bool) Place {
+	place := Place{Locations: []string{}, Artists: []models.Artist{}, Concerts: []PlaceConcert{}}
+	for i := range artists {
+		artist := &artists[i]
+		played := false
+		for _, concert := range artist.Relations.Concerts() {
+			if !match(concert.Location) {
+				continue
+			}
+			played = true
+			place.Concerts = append(place.Concerts, PlaceConcert{Concert: concert, ArtistID: artist.Artist.ID, ArtistName: artist.Artist.Name})
+			if !containsString(place.Locations, concert.Location) {
+				place.Locations = append(place.Locations, concert.Location)
+			}
+		}
+		if played {
+			place.Artists = append(place.Artists, artist.Artist)
+		}
+	}
+
+	sort.Strings(place.Locations)
+	sort.Slice(place.Artists, func(i, j int) bool { return place.Artists[i].Name < place.Artists[j].Name })
+	sort.SliceStable(place.Concerts, func(i, j int) bool {
+		if place.Concerts[i].Date.Equal(place.Concerts[j].Date) {
+			return place.Concerts[i].ArtistName < place.Concerts[j].ArtistName
+		}
+		return place.Concerts[i].Date.Before(place.Concerts[j].Date)
+	})
+	return place
+}
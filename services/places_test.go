@@ -0,0 +1,108 @@
+package services
+
+import (
+	"groopie_local/models"
+	"slices"
+	"testing"
+)
+
+// placeSummary lists the place's concerts as "artist at location on date", in order.
+func placeSummary(p Place) []string {
+	var summary []string
+	for _, concert := range p.Concerts {
+		summary = append(summary, concert.ArtistName+" at "+concert.Location+" on "+concert.Date.Format("02-01-2006"))
+	}
+	return summary
+}
+
+func TestFindPlace(t *testing.T) {
+	artists := []models.ArtistFull{
+		artistFull(1, "Queen", nil, map[string][]string{"london-uk": {"02-01-2020"}, "leeds-uk": {"01-01-2020"}, "paris-france": {"03-01-2020"}}),
+		artistFull(2, "AC/DC", nil, map[string][]string{"london-uk": {"02-01-2020", "05-01-2020"}}),
+		artistFull(3, "ABBA", nil, map[string][]string{"auckland-new_zealand": {"04-01-2020"}}),
+	}
+
+	tests := []struct {
+		name      string
+		find      func([]models.ArtistFull, string) (Place, bool)
+		slug      string
+		found     bool
+		placeName string
+		country   string
+		locations []string
+		artists   []string
+		concerts  []string // placeSummary of the place.
+	}{
+		{
+			name: "location", find: FindLocation, slug: "london-uk", found: true,
+			placeName: "london, uk", country: "uk",
+			locations: []string{"london-uk"},
+			artists:   []string{"AC/DC", "Queen"},
+			concerts:  []string{"AC/DC at london-uk on 02-01-2020", "Queen at london-uk on 02-01-2020", "AC/DC at london-uk on 05-01-2020"},
+		},
+		{
+			name: "country", find: FindCountry, slug: "uk", found: true,
+			placeName: "uk", country: "uk",
+			locations: []string{"leeds-uk", "london-uk"},
+			artists:   []string{"AC/DC", "Queen"},
+			concerts: []string{
+				"Queen at leeds-uk on 01-01-2020",
+				"AC/DC at london-uk on 02-01-2020",
+				"Queen at london-uk on 02-01-2020",
+				"AC/DC at london-uk on 05-01-2020",
+			},
+		},
+		{
+			name: "country with a space", find: FindCountry, slug: "new_zealand", found: true,
+			placeName: "new zealand", country: "new_zealand",
+			locations: []string{"auckland-new_zealand"},
+			artists:   []string{"ABBA"},
+			concerts:  []string{"ABBA at auckland-new_zealand on 04-01-2020"},
+		},
+		{
+			name: "unknown location", find: FindLocation, slug: "rome-italy",
+			placeName: "rome, italy", country: "italy", locations: []string{}, artists: []string{},
+		},
+		{
+			name: "location is not a country", find: FindCountry, slug: "london-uk",
+			placeName: "london-uk", country: "london-uk", locations: []string{}, artists: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			place, found := tt.find(artists, tt.slug)
+			if found != tt.found || place.Slug != tt.slug || place.Name != tt.placeName || place.Country != tt.country {
+				t.Errorf("place %q (%q in %q), found %v, want %q (%q in %q), found %v",
+					place.Slug, place.Name, place.Country, found, tt.slug, tt.placeName, tt.country, tt.found)
+			}
+			if !slices.Equal(place.Locations, tt.locations) {
+				t.Errorf("locations = %v, want %v", place.Locations, tt.locations)
+			}
+			var names []string
+			for _, artist := range place.Artists {
+				names = append(names, artist.Name)
+			}
+			if !slices.Equal(names, tt.artists) {
+				t.Errorf("artists = %v, want %v", names, tt.artists)
+			}
+			if got := placeSummary(place); !slices.Equal(got, tt.concerts) {
+				t.Errorf("concerts = %v, want %v", got, tt.concerts)
+			}
+		})
+	}
+}
+
+func TestCountryCode(t *testing.T) {
+	tests := []struct{ location, code, name string }{
+		{"london-uk", "uk", "uk"},
+		{"auckland-new_zealand", "new_zealand", "new zealand"},
+		{"north_carolina-usa", "usa", "usa"},
+		{"nowhere", "", ""},
+	}
+	for _, tt := range tests {
+		code := CountryCode(tt.location)
+		if code != tt.code || CountryName(code) != tt.name {
+			t.Errorf("CountryCode(%q) = %q named %q, want %q named %q", tt.location, code, CountryName(code), tt.code, tt.name)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"groopie_local/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSavedSearches bounds the number of saved searches per user.
+	maxSavedSearches = 50
+	// maxNotifications bounds the number of notifications kept per user.
+	maxNotifications = 50
+	// searchCodeAlphabet is used for the short codes of saved searches; ambiguous characters are left out.
+	searchCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// searchCodeLength is the number of characters of a short code.
+	searchCodeLength = 7
+)
+
+var (
+	// ErrSearchNotFound is returned for unknown saved search codes.
+	ErrSearchNotFound = errors.New("saved search not found")
+	// ErrInvalidSearchName is returned when a saved search name is empty or too long.
+	ErrInvalidSearchName = errors.New("name must be between 1 and 64 characters")
+	// ErrTooManySearches is returned when a user already has maxSavedSearches saved searches.
+	ErrTooManySearches = errors.New("too many saved searches")
+)
+
+var (
+	// searchOwners maps the code of every saved search to the username of its owner, so shared searches
+	// are found without listing every user. It is loaded from the user store on first use.
+	searchOwners map[string]string
+	// searchOwnersLock guards searchOwners.
+	searchOwnersLock sync.Mutex
+)
+
+// SaveSearch stores the filters under the given name for the user and returns the saved search
+// with its newly generated short code. resultIDs are the artists currently matched by the filters.
+func SaveSearch(username, name string, filters models.Filters, notify bool, resultIDs []int) (models.SavedSearch, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return models.SavedSearch{}, ErrInvalidSearchName
+	}
+
+	code, err := newSearchCode(username)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	search := models.SavedSearch{
+		Code:      code,
+		Name:      name,
+		Filters:   filters,
+		Notify:    notify,
+		ResultIDs: resultIDs,
+		CreatedAt: time.Now(),
+	}
+
+	var tooMany bool
+	_, err = UpdateUser(username, func(user *models.User) {
+		if len(user.SavedSearches) >= maxSavedSearches {
+			tooMany = true
+			return
+		}
+		user.SavedSearches = append(user.SavedSearches, search)
+	})
+	if err != nil || tooMany {
+		releaseSearchCode(code)
+	}
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	if tooMany {
+		return models.SavedSearch{}, ErrTooManySearches
+	}
+	return search, nil
+}
+
+// RenameSearch changes the name of one of the user's saved searches.
+func RenameSearch(username, code, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return ErrInvalidSearchName
+	}
+	return updateSearch(username, code, func(search *models.SavedSearch) {
+		search.Name = name
+	})
+}
+
+// SetSearchNotify turns change notifications on or off for one of the user's saved searches.
+func SetSearchNotify(username, code string, notify bool) error {
+	return updateSearch(username, code, func(search *models.SavedSearch) {
+		search.Notify = notify
+	})
+}
+
+// DeleteSearch removes one of the user's saved searches.
+func DeleteSearch(username, code string) error {
+	found := false
+	_, err := UpdateUser(username, func(user *models.User) {
+		var kept []models.SavedSearch
+		for _, search := range user.SavedSearches {
+			if search.Code == code {
+				found = true
+				continue
+			}
+			kept = append(kept, search)
+		}
+		user.SavedSearches = kept
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrSearchNotFound
+	}
+	releaseSearchCode(code)
+	return nil
+}
+
+// RecordSearchResults stores the artists currently matched by a saved search and, when message is
+// not empty, adds a notification for the user. Older notifications beyond maxNotifications are dropped.
+func RecordSearchResults(username, code string, resultIDs []int, message string) error {
+	found := false
+	_, err := UpdateUser(username, func(user *models.User) {
+		search := user.SavedSearch(code)
+		if search == nil {
+			return
+		}
+		found = true
+		search.ResultIDs = resultIDs
+		if message == "" {
+			return
+		}
+		user.Notifications = append(user.Notifications, models.Notification{
+			Time:       time.Now(),
+			SearchCode: code,
+			Message:    message,
+		})
+		if len(user.Notifications) > maxNotifications {
+			user.Notifications = user.Notifications[len(user.Notifications)-maxNotifications:]
+		}
+	})
+	if err == nil && !found {
+		return ErrSearchNotFound
+	}
+	return err
+}
+
+// ClearNotifications removes every notification of the user.
+func ClearNotifications(username string) error {
+	_, err := UpdateUser(username, func(user *models.User) {
+		user.Notifications = nil
+	})
+	return err
+}
+
+// FindSharedSearch returns the saved search with the given short code, whoever owns it.
+func FindSharedSearch(code string) (models.SavedSearch, error) {
+	searchOwnersLock.Lock()
+	owners, err := searchOwnersLocked()
+	username, found := owners[code]
+	searchOwnersLock.Unlock()
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	if !found {
+		return models.SavedSearch{}, ErrSearchNotFound
+	}
+
+	user, err := GetUser(username)
+	if errors.Is(err, ErrUserNotFound) {
+		return models.SavedSearch{}, ErrSearchNotFound
+	}
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	if search := user.SavedSearch(code); search != nil {
+		return *search, nil
+	}
+	return models.SavedSearch{}, ErrSearchNotFound
+}
+
+// searchOwnersLocked returns the owners of the saved searches by code, loading them from the user store
+// the first time. The caller must hold searchOwnersLock.
+func searchOwnersLocked() (map[string]string, error) {
+	if searchOwners != nil {
+		return searchOwners, nil
+	}
+	all, err := ListUsers()
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]string)
+	for _, user := range all {
+		for _, search := range user.SavedSearches {
+			owners[search.Code] = user.Username
+		}
+	}
+	searchOwners = owners
+	return owners, nil
+}
+
+// releaseSearchCode removes a code from the index of saved searches, once its search is deleted or was not saved.
+func releaseSearchCode(code string) {
+	searchOwnersLock.Lock()
+	defer searchOwnersLock.Unlock()
+	delete(searchOwners, code)
+}
+
+// updateSearch applies update to one of the user's saved searches.
+func updateSearch(username, code string, update func(search *models.SavedSearch)) error {
+	found := false
+	_, err := UpdateUser(username, func(user *models.User) {
+		if search := user.SavedSearch(code); search != nil {
+			found = true
+			update(search)
+		}
+	})
+	if err == nil && !found {
+		return ErrSearchNotFound
+	}
+	return err
+}
+
+// newSearchCode generates a short code that is not used by any saved search yet,
+// and reserves it for a search of the given user.
+func newSearchCode(username string) (string, error) {
+	searchOwnersLock.Lock()
+	defer searchOwnersLock.Unlock()
+	owners, err := searchOwnersLocked()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		b := make([]byte, searchCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for i := range b {
+			b[i] = searchCodeAlphabet[int(b[i])%len(searchCodeAlphabet)]
+		}
+		code := string(b)
+
+		if _, taken := owners[code]; !taken {
+			owners[code] = username
+			return code, nil
+		}
+	}
+}
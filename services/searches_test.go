@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"groopie_local/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindSharedSearch(t *testing.T) {
+	store, err := NewFileUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := models.SavedSearch{Code: "abcdefg", Name: "Existing"}
+	for _, user := range []models.User{{Username: "alice", SavedSearches: []models.SavedSearch{existing}}, {Username: "bob"}} {
+		if err := store.CreateUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ConfigureUserStore(store)
+	defer ConfigureUserStore(nil)
+
+	// Searches saved before the index was loaded are found.
+	if search, err := FindSharedSearch("abcdefg"); err != nil || search.Name != "Existing" {
+		t.Errorf("FindSharedSearch(existing) = %+v, %v", search, err)
+	}
+
+	saved, err := SaveSearch("bob", "Rock", models.Filters{SearchQuery: "rock"}, false, nil)
+	if err != nil {
+		t.Fatalf("SaveSearch() = %v", err)
+	}
+	if search, err := FindSharedSearch(saved.Code); err != nil || search.Name != "Rock" {
+		t.Errorf("FindSharedSearch(saved) = %+v, %v", search, err)
+	}
+
+	if err := DeleteSearch("bob", saved.Code); err != nil {
+		t.Fatalf("DeleteSearch() = %v", err)
+	}
+	if _, err := FindSharedSearch(saved.Code); !errors.Is(err, ErrSearchNotFound) {
+		t.Errorf("FindSharedSearch(deleted) = %v, want ErrSearchNotFound", err)
+	}
+	if _, err := FindSharedSearch("unknown"); !errors.Is(err, ErrSearchNotFound) {
+		t.Errorf("FindSharedSearch(unknown) = %v, want ErrSearchNotFound", err)
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"groopie_local/models"
+	"sort"
+	"strings"
+)
+
+// Weights of the similarity criteria. They add up to 1 so scores stay between 0 and 1.
+const (
+	locationWeight = 0.4
+	tourWeight     = 0.25
+	eraWeight      = 0.2
+	memberWeight   = 0.15
+)
+
+// eraSpan is the difference in creation years beyond which two artists share nothing of their era.
+const eraSpan = 20
+
+// SimilarArtist is an artist ranked by its similarity to another artist, with the reasons for it.
+type SimilarArtist struct {
+	Artist          models.Artist `json:"artist"`
+	Score           float64       `json:"score"` // Between 0 and 1.
+	Reasons         []string      `json:"reasons"`
+	SharedLocations []string      `json:"sharedLocations"`
+}
+
+// Percent returns the score as a whole percentage.
+func (s SimilarArtist) Percent() int {
+	return int(s.Score*100 + 0.5)
+}
+
+// SimilarArtists ranks the other artists by similarity to the artist with the given ID and returns the
+// limit best ones (all when limit <= 0). Similarity combines the shared performance locations, the months
+// both artists toured in, how close their creation years are and how close their member counts are.
+// Artists that have nothing in common are left out.
+func SimilarArtists(artists []models.ArtistFull, artistID, limit int) []SimilarArtist {
+	var target *models.ArtistFull
+	for i := range artists {
+		if artists[i].Artist.ID == artistID {
+			target = &artists[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	targetLocations := locationSet(target.Relations)
+	targetMonths := tourMonths(target.Relations)
+
+	var similar []SimilarArtist
+	for i := range artists {
+		other := &artists[i]
+		if other.Artist.ID == artistID {
+			continue
+		}
+		result := SimilarArtist{Artist: other.Artist}
+
+		shared, locationScore := overlap(targetLocations, locationSet(other.Relations))
+		if len(shared) > 0 {
+			result.SharedLocations = shared
+			result.Reasons = append(result.Reasons, sharedLocationsReason(shared))
+		}
+
+		months, tourScore := overlap(targetMonths, tourMonths(other.Relations))
+		if len(months) > 0 {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("Tour dates overlap in %s", plural(len(months), "month", "months")))
+		}
+
+		eraScore := 0.0
+		if gap := abs(target.Artist.CreationDate - other.Artist.CreationDate); gap < eraSpan {
+			eraScore = 1 - float64(gap)/eraSpan
+			switch {
+			case gap == 0:
+				result.Reasons = append(result.Reasons, fmt.Sprintf("Formed the same year (%d)", other.Artist.CreationDate))
+			case gap <= 5:
+				result.Reasons = append(result.Reasons, fmt.Sprintf("Formed %s apart (%d and %d)",
+					plural(gap, "year", "years"), target.Artist.CreationDate, other.Artist.CreationDate))
+			}
+		}
+
+		memberScore := 0.0
+		a, b := len(target.Artist.Members), len(other.Artist.Members)
+		if size := max(a, b); size > 0 {
+			memberScore = 1 - float64(abs(a-b))/float64(size)
+			switch abs(a - b) {
+			case 0:
+				result.Reasons = append(result.Reasons, fmt.Sprintf("Same number of members (%d)", a))
+			case 1:
+				result.Reasons = append(result.Reasons, fmt.Sprintf("Similar line-up size (%d and %d members)", a, b))
+			}
+		}
+
+		if len(result.Reasons) == 0 {
+			continue
+		}
+		result.Score = locationWeight*locationScore + tourWeight*tourScore + eraWeight*eraScore + memberWeight*memberScore
+		similar = append(similar, result)
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Score == similar[j].Score {
+			return similar[i].Artist.Name < similar[j].Artist.Name
+		}
+		return similar[i].Score > similar[j].Score
+	})
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar
+}
+
+// locationSet returns the set of locations the artist performed at.
+func locationSet(relations models.Relations) map[string]bool {
+	set := make(map[string]bool, len(relations.DatesLocations))
+	for location := range relations.DatesLocations {
+		set[location] = true
+	}
+	return set
+}
+
+// tourMonths returns the set of months ("2006-01") in which the artist performed.
+func tourMonths(relations models.Relations) map[string]bool {
+	set := make(map[string]bool)
+	for _, concert := range relations.Concerts() {
+		set[concert.Date.Format("2006-01")] = true
+	}
+	return set
+}
+
+// overlap returns the sorted elements common to a and b and their Jaccard index.
+func overlap(a, b map[string]bool) ([]string, float64) {
+	var common []string
+	for key := range a {
+		if b[key] {
+			common = append(common, key)
+		}
+	}
+	union := len(a) + len(b) - len(common)
+	if union == 0 {
+		return nil, 0
+	}
+	sort.Strings(common)
+	return common, float64(len(common)) / float64(union)
+}
+
+// sharedLocationsReason describes the shared locations, naming at most three of them.
+func sharedLocationsReason(shared []string) string {
+	names := make([]string, 0, 3)
+	for i, location := range shared {
+		if i == 3 {
+			break
+		}
+		names = append(names, LocationQuery(location))
+	}
+	reason := fmt.Sprintf("Played %s in common: %s", plural(len(shared), "location", "locations"), strings.Join(names, "; "))
+	if len(shared) > len(names) {
+		reason += fmt.Sprintf(" and %d more", len(shared)-len(names))
+	}
+	return reason
+}
+
+// plural formats n followed by the singular or plural form of a word.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"groopie_local/models"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSimilarArtists(t *testing.T) {
+	withCreation := func(artist models.ArtistFull, year int) models.ArtistFull {
+		artist.Artist.CreationDate = year
+		return artist
+	}
+	four := []string{"Freddie", "Brian", "Roger", "John"}
+	three := []string{"Steven", "Joe", "Tom"}
+	artists := []models.ArtistFull{
+		withCreation(artistFull(1, "Queen", four, map[string][]string{"london-uk": {"01-01-2020"}, "paris-france": {"05-01-2020"}}), 1970),
+		withCreation(artistFull(2, "Genesis", four, map[string][]string{"london-uk": {"10-01-2020"}}), 1970),
+		withCreation(artistFull(3, "Yes", three, nil), 1973),
+		withCreation(artistFull(4, "Solo", []string{"Prince"}, nil), 2000),
+		withCreation(artistFull(5, "Aerosmith", three, nil), 1973),
+	}
+
+	type result struct {
+		name    string
+		score   float64
+		reasons []string
+	}
+	genesis := result{"Genesis", 0.8, []string{
+		"Played 1 location in common: london, uk",
+		"Tour dates overlap in 1 month",
+		"Formed the same year (1970)",
+		"Same number of members (4)",
+	}}
+	threeMembers := []string{"Formed 3 years apart (1970 and 1973)", "Similar line-up size (4 and 3 members)"}
+
+	tests := []struct {
+		name     string
+		artistID int
+		limit    int
+		want     []result
+	}{
+		{
+			name:     "ranked by score then name",
+			artistID: 1,
+			want:     []result{genesis, {"Aerosmith", 0.2825, threeMembers}, {"Yes", 0.2825, threeMembers}},
+		},
+		{name: "limited", artistID: 1, limit: 1, want: []result{genesis}},
+		{name: "unknown artist", artistID: 6},
+		{name: "nothing in common", artistID: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			similar := SimilarArtists(artists, tt.artistID, tt.limit)
+			if len(similar) != len(tt.want) {
+				t.Fatalf("SimilarArtists() returned %d artists, want %d: %+v", len(similar), len(tt.want), similar)
+			}
+			for i, got := range similar {
+				want := tt.want[i]
+				if got.Artist.Name != want.name || math.Abs(got.Score-want.score) > 1e-9 || !slices.Equal(got.Reasons, want.reasons) {
+					t.Errorf("artist %d = %s scored %v for %q, want %s scored %v for %q",
+						i, got.Artist.Name, got.Score, got.Reasons, want.name, want.score, want.reasons)
+				}
+			}
+		})
+	}
+}
+
+func TestSharedLocationsReason(t *testing.T) {
+	tests := []struct {
+		shared []string
+		want   string
+	}{
+		{[]string{"london-uk"}, "Played 1 location in common: london, uk"},
+		{
+			[]string{"berlin-germany", "london-uk", "paris-france"},
+			"Played 3 locations in common: berlin, germany; london, uk; paris, france",
+		},
+		{
+			[]string{"berlin-germany", "london-uk", "paris-france", "rome-italy", "oslo-norway"},
+			"Played 5 locations in common: berlin, germany; london, uk; paris, france and 2 more",
+		},
+	}
+	for _, tt := range tests {
+		if got := sharedLocationsReason(tt.shared); got != tt.want {
+			t.Errorf("sharedLocationsReason(%q) = %q, want %q", tt.shared, got, tt.want)
+		}
+	}
+}
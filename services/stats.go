@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"groopie_local/models"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// busiestCitiesLimit is the number of cities listed in Stats.BusiestCities.
+const busiestCitiesLimit = 10
+
+// CountStat is one bar of a histogram: a label and how many items it counts.
+type CountStat struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+	// Percent is Count relative to the largest count of the histogram, to draw the bars.
+	Percent int `json:"-"`
+}
+
+// Stats aggregates the whole dataset. It is recomputed every time the cache is refreshed.
+type Stats struct {
+	GeneratedAt              time.Time   `json:"generatedAt"`
+	Artists                  int         `json:"artists"`
+	Concerts                 int         `json:"concerts"`
+	Locations                int         `json:"locations"`
+	Countries                int         `json:"countries"`
+	ConcertsByCountry        []CountStat `json:"concertsByCountry"`
+	ConcertsByYear           []CountStat `json:"concertsByYear"`
+	BusiestCities            []CountStat `json:"busiestCities"`
+	MemberCounts             []CountStat `json:"memberCounts"`
+	FormationYears           []CountStat `json:"formationYears"`
+	AverageYearsToFirstAlbum float64     `json:"averageYearsToFirstAlbum"`
+}
+
+// stats holds the statistics of the cached data. It is guarded by cacheLock.
+var stats Stats
+
+// GetStats returns the statistics of the cached data, refreshing the cache first if it expired.
+func GetStats(ctx context.Context) (Stats, error) {
+	if _, err := GetCachedData(ctx); err != nil {
+		return Stats{}, err
+	}
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	return stats, nil
+}
+
+// ComputeStats aggregates the concerts per country, year and city, the number of members, the formation
+// years and the average number of years between an artist's formation and its first album.
+func ComputeStats(artists []models.ArtistFull) Stats {
+	result := Stats{GeneratedAt: time.Now(), Artists: len(artists)}
+
+	byCountry := make(map[string]int)
+	byYear := make(map[string]int)
+	byCity := make(map[string]int)
+	byMembers := make(map[string]int)
+	byFormation := make(map[string]int)
+	var gapTotal, gapCount int
+
+	for i := range artists {
+		artist := &artists[i]
+		for _, concert := range artist.Relations.Concerts() {
+			result.Concerts++
+			byCountry[CountryName(CountryCode(concert.Location))]++
+			byYear[strconv.Itoa(concert.Date.Year())]++
+			byCity[LocationQuery(concert.Location)]++
+		}
+
+		byMembers[strconv.Itoa(len(artist.Artist.Members))]++
+		if artist.Artist.CreationDate > 0 {
+			byFormation[strconv.Itoa(artist.Artist.CreationDate)]++
+		}
+		if album, err := time.Parse(models.ConcertDateLayout, artist.Artist.FirstAlbum); err == nil && artist.Artist.CreationDate > 0 {
+			gapTotal += album.Year() - artist.Artist.CreationDate
+			gapCount++
+		}
+	}
+
+	result.Locations = len(byCity)
+	result.Countries = len(byCountry)
+	result.ConcertsByCountry = countStats(byCountry, byCount)
+	result.ConcertsByYear = countStats(byYear, byNumericLabel)
+	result.BusiestCities = countStats(byCity, byCount)
+	if len(result.BusiestCities) > busiestCitiesLimit {
+		result.BusiestCities = result.BusiestCities[:busiestCitiesLimit]
+	}
+	result.MemberCounts = countStats(byMembers, byNumericLabel)
+	result.FormationYears = countStats(byFormation, byNumericLabel)
+	if gapCount > 0 {
+		result.AverageYearsToFirstAlbum = float64(gapTotal) / float64(gapCount)
+	}
+	return result
+}
+
+// countStats converts counts into a histogram ordered by less, with the bar percentages filled in.
+func countStats(counts map[string]int, less func(a, b CountStat) bool) []CountStat {
+	histogram := make([]CountStat, 0, len(counts))
+	largest := 0
+	for label, count := range counts {
+		histogram = append(histogram, CountStat{Label: label, Count: count})
+		largest = max(largest, count)
+	}
+	for i := range histogram {
+		histogram[i].Percent = histogram[i].Count * 100 / largest
+	}
+	sort.Slice(histogram, func(i, j int) bool { return less(histogram[i], histogram[j]) })
+	return histogram
+}
+
+// byCount orders histogram bars from the largest count to the smallest, then by label.
+func byCount(a, b CountStat) bool {
+	if a.Count == b.Count {
+		return a.Label < b.Label
+	}
+	return a.Count > b.Count
+}
+
+// byNumericLabel orders histogram bars by their label, compared as numbers ("9" before "10").
+func byNumericLabel(a, b CountStat) bool {
+	if len(a.Label) != len(b.Label) {
+		return len(a.Label) < len(b.Label)
+	}
+	return a.Label < b.Label
+}
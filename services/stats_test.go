@@ -0,0 +1,108 @@
+package services
+
+import (
+	"groopie_local/models"
+	"reflect"
+	"testing"
+)
+
+// statsArtist returns an artist formed in the given year, with a first album and concerts keyed by location.
+func statsArtist(id int, members []string, created int, firstAlbum string, concerts map[string][]string) models.ArtistFull {
+	artist := artistFull(id, "Artist", members, concerts)
+	artist.Artist.CreationDate = created
+	artist.Artist.FirstAlbum = firstAlbum
+	return artist
+}
+
+func TestComputeStats(t *testing.T) {
+	artists := []models.ArtistFull{
+		statsArtist(1, []string{"a", "b", "c", "d"}, 1970, "14-12-1973", map[string][]string{
+			"london-uk":    {"01-01-2019", "02-01-2020"},
+			"paris-france": {"03-01-2020"},
+		}),
+		statsArtist(2, []string{"e"}, 1973, "01-01-1975", map[string][]string{
+			"london-uk":            {"04-01-2020"},
+			"new_york-usa":         {"05-01-2021"},
+			"los_angeles-usa":      {"06-01-2021"},
+			"saint_denis-france":   {"07-01-2021"},
+			"invalid_date-nowhere": {"not a date"},
+		}),
+		// No formation year nor a parsable first album: left out of both aggregates.
+		statsArtist(3, []string{"f", "g", "h", "i"}, 0, "unknown", nil),
+		statsArtist(4, []string{"j"}, 1970, "unknown", nil),
+	}
+	stats := ComputeStats(artists)
+
+	tests := []struct {
+		name string
+		got  []CountStat
+		want []CountStat
+	}{
+		{
+			name: "concerts by country, largest first",
+			got:  stats.ConcertsByCountry,
+			want: []CountStat{{"uk", 3, 100}, {"france", 2, 66}, {"usa", 2, 66}},
+		},
+		{
+			name: "concerts by year",
+			got:  stats.ConcertsByYear,
+			want: []CountStat{{"2019", 1, 33}, {"2020", 3, 100}, {"2021", 3, 100}},
+		},
+		{
+			name: "busiest cities",
+			got:  stats.BusiestCities,
+			want: []CountStat{{"london, uk", 3, 100}, {"los angeles, usa", 1, 33}, {"new york, usa", 1, 33}, {"paris, france", 1, 33}, {"saint denis, france", 1, 33}},
+		},
+		{
+			name: "member counts, by number",
+			got:  stats.MemberCounts,
+			want: []CountStat{{"1", 2, 100}, {"4", 2, 100}},
+		},
+		{
+			name: "formation years",
+			got:  stats.FormationYears,
+			want: []CountStat{{"1970", 2, 100}, {"1973", 1, 50}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	if stats.Artists != 4 || stats.Concerts != 7 || stats.Locations != 5 || stats.Countries != 3 {
+		t.Errorf("totals = %d artists, %d concerts, %d locations, %d countries, want 4, 7, 5, 3",
+			stats.Artists, stats.Concerts, stats.Locations, stats.Countries)
+	}
+	// Three years from 1970 to 1973 and two from 1973 to 1975.
+	if stats.AverageYearsToFirstAlbum != 2.5 {
+		t.Errorf("AverageYearsToFirstAlbum = %v, want 2.5", stats.AverageYearsToFirstAlbum)
+	}
+}
+
+func TestComputeStatsEmpty(t *testing.T) {
+	stats := ComputeStats(nil)
+	if stats.Concerts != 0 || len(stats.ConcertsByCountry) != 0 || stats.AverageYearsToFirstAlbum != 0 {
+		t.Errorf("ComputeStats(nil) = %+v", stats)
+	}
+}
+
+func TestByNumericLabel(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"9", "10", true},
+		{"10", "9", false},
+		{"1970", "1973", true},
+		{"2", "2", false},
+	}
+	for _, tt := range tests {
+		if got := byNumericLabel(CountStat{Label: tt.a}, CountStat{Label: tt.b}); got != tt.want {
+			t.Errorf("byNumericLabel(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"groopie_local/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// earthRadiusKm is the mean radius of the Earth used for great-circle distances.
+const earthRadiusKm = 6371.0
+
+// TourLeg is the journey between two consecutive concerts at different locations.
+type TourLeg struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	FromDate   time.Time `json:"fromDate"`
+	ToDate     time.Time `json:"toDate"`
+	DistanceKm float64   `json:"distanceKm"`
+}
+
+// TourStats summarises an artist's tour. The legs and distances are only filled in by AnalyzeTour,
+// since they require the coordinates of every location.
+type TourStats struct {
+	ArtistID             int       `json:"artistId"`
+	Concerts             int       `json:"concerts"`
+	Countries            []string  `json:"countries"`    // Country codes, as in CountryCode.
+	BusiestMonth         string    `json:"busiestMonth"` // "2006-01", empty without concerts.
+	BusiestMonthConcerts int       `json:"busiestMonthConcerts"`
+	Legs                 []TourLeg `json:"legs"`
+	TotalKm              float64   `json:"totalKm"`
+	LongestLeg           *TourLeg  `json:"longestLeg"`
+	Unresolved           []string  `json:"unresolved"` // Locations not geocoded yet, or that could not be.
+}
+
+// TourSummary counts the concerts of the artist, the countries visited and the busiest month.
+func TourSummary(artist models.ArtistFull) TourStats {
+	stats := TourStats{ArtistID: artist.Artist.ID, Countries: []string{}}
+	countries := make(map[string]bool)
+	months := make(map[string]int)
+
+	for _, concert := range artist.Relations.Concerts() {
+		stats.Concerts++
+		if country := CountryCode(concert.Location); country != "" && !countries[country] {
+			countries[country] = true
+			stats.Countries = append(stats.Countries, country)
+		}
+		months[concert.Date.Format("2006-01")]++
+	}
+	sort.Strings(stats.Countries)
+
+	for month, count := range months {
+		// Ties go to the earliest month so the result does not depend on map order.
+		if count > stats.BusiestMonthConcerts || count == stats.BusiestMonthConcerts && month < stats.BusiestMonth {
+			stats.BusiestMonth = month
+			stats.BusiestMonthConcerts = count
+		}
+	}
+	return stats
+}
+
+// AnalyzeTour completes TourSummary with the legs of the tour in chronological order, the total distance
+// travelled and the longest leg. It only uses the coordinates already geocoded, so it never waits for Nominatim:
+// the other locations are queued for the background geocoder and listed in Unresolved. No leg is reported
+// to or from an unresolved stop, nor across it, so the distances only cover hops that are known.
+func AnalyzeTour(artist models.ArtistFull) TourStats {
+	stats := TourSummary(artist)
+	stats.Legs = []TourLeg{}
+	stats.Unresolved = []string{}
+
+	coords := make(map[string]models.Coordinates)
+	var previous *models.Concert
+	for _, concert := range artist.Relations.Concerts() {
+		if _, ok := coords[concert.Location]; !ok {
+			c, ok := CachedCoordinates(concert.Location)
+			if !ok {
+				if !containsString(stats.Unresolved, concert.Location) {
+					stats.Unresolved = append(stats.Unresolved, concert.Location)
+				}
+				previous = nil
+				continue
+			}
+			coords[concert.Location] = c
+		}
+
+		if previous != nil && previous.Location != concert.Location {
+			stats.Legs = append(stats.Legs, TourLeg{
+				From:       previous.Location,
+				To:         concert.Location,
+				FromDate:   previous.Date,
+				ToDate:     concert.Date,
+				DistanceKm: Distance(coords[previous.Location], coords[concert.Location]),
+			})
+		}
+		current := concert
+		previous = &current
+	}
+
+	for i := range stats.Legs {
+		stats.TotalKm += stats.Legs[i].DistanceKm
+		if stats.LongestLeg == nil || stats.Legs[i].DistanceKm > stats.LongestLeg.DistanceKm {
+			stats.LongestLeg = &stats.Legs[i]
+		}
+	}
+	return stats
+}
+
+// Distance returns the great-circle distance in kilometres between two coordinates (haversine formula).
+func Distance(a, b models.Coordinates) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"groopie_local/models"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// londonParisKm is the great-circle distance between the coordinates used for London and Paris.
+const londonParisKm = 343.5
+
+func TestAnalyzeTour(t *testing.T) {
+	geocodeLock.Lock()
+	geocodeCache["london-uk"] = models.Coordinates{Lat: 51.5074, Lon: -0.1278}
+	geocodeCache["paris-france"] = models.Coordinates{Lat: 48.8566, Lon: 2.3522}
+	geocodeLock.Unlock()
+	t.Cleanup(func() {
+		geocodeLock.Lock()
+		delete(geocodeCache, "london-uk")
+		delete(geocodeCache, "paris-france")
+		geocodeQueue, geocodeQueued = nil, make(map[string]bool)
+		geocodeLock.Unlock()
+	})
+
+	type leg struct{ from, to string }
+	tests := []struct {
+		name       string
+		concerts   map[string][]string
+		countries  []string
+		legs       []leg
+		unresolved []string
+	}{
+		{
+			name:      "every stop located",
+			concerts:  map[string][]string{"london-uk": {"01-01-2020", "01-03-2020"}, "paris-france": {"01-02-2020"}},
+			countries: []string{"france", "uk"},
+			legs:      []leg{{"london-uk", "paris-france"}, {"paris-france", "london-uk"}},
+		},
+		{
+			// The stop without coordinates breaks the tour: no leg bridges London to Paris across it.
+			name: "middle stop without coordinates",
+			concerts: map[string][]string{
+				"london-uk":                {"01-01-2020"},
+				"nowhere_land-new_zealand": {"01-02-2020"},
+				"paris-france":             {"01-03-2020", "01-05-2020"},
+			},
+			countries:  []string{"france", "new_zealand", "uk"},
+			legs:       []leg{},
+			unresolved: []string{"nowhere_land-new_zealand"},
+		},
+		{
+			name: "tour resumes after the gap",
+			concerts: map[string][]string{
+				"paris-france":             {"01-01-2020", "01-03-2020"},
+				"nowhere_land-new_zealand": {"01-02-2020", "01-05-2020"},
+				"london-uk":                {"01-04-2020"},
+			},
+			countries:  []string{"france", "new_zealand", "uk"},
+			legs:       []leg{{"paris-france", "london-uk"}},
+			unresolved: []string{"nowhere_land-new_zealand"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := AnalyzeTour(artistFull(1, "Queen", nil, tt.concerts))
+
+			if !reflect.DeepEqual(stats.Countries, tt.countries) {
+				t.Errorf("Countries = %v, want %v", stats.Countries, tt.countries)
+			}
+			if tt.unresolved == nil {
+				tt.unresolved = []string{}
+			}
+			if !reflect.DeepEqual(stats.Unresolved, tt.unresolved) {
+				t.Errorf("Unresolved = %v, want %v", stats.Unresolved, tt.unresolved)
+			}
+			legs := []leg{}
+			for _, l := range stats.Legs {
+				legs = append(legs, leg{l.From, l.To})
+				if math.Abs(l.DistanceKm-londonParisKm) > 1 {
+					t.Errorf("leg %s to %s = %v km, want about %v km", l.From, l.To, l.DistanceKm, londonParisKm)
+				}
+			}
+			if !reflect.DeepEqual(legs, tt.legs) {
+				t.Errorf("legs = %v, want %v", legs, tt.legs)
+			}
+			if want := float64(len(tt.legs)) * londonParisKm; math.Abs(stats.TotalKm-want) > float64(len(tt.legs)) {
+				t.Errorf("TotalKm = %v, want about %v", stats.TotalKm, want)
+			}
+			if (stats.LongestLeg == nil) != (len(tt.legs) == 0) {
+				t.Errorf("LongestLeg = %+v with %d legs", stats.LongestLeg, len(tt.legs))
+			}
+		})
+	}
+
+	// Unresolved locations are queued for the background geocoder rather than looked up.
+	geocodeLock.Lock()
+	queued := geocodeQueued["nowhere_land-new_zealand"]
+	geocodeLock.Unlock()
+	if !queued {
+		t.Error("the unresolved location was not queued for geocoding")
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"groopie_local/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrUserExists is returned when registering a username that is already taken.
+	ErrUserExists = errors.New("user already exists")
+	// ErrUserNotFound is returned when looking up an unknown username.
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// UserStore persists user accounts.
+type UserStore interface {
+	// CreateUser stores a new user, or returns ErrUserExists if the username is taken.
+	CreateUser(user models.User) error
+	// GetUser returns the user with the given username, or ErrUserNotFound.
+	GetUser(username string) (models.User, error)
+	// UpdateUser replaces an existing user, or returns ErrUserNotFound.
+	UpdateUser(user models.User) error
+	// ListUsers returns every user ordered by username.
+	ListUsers() ([]models.User, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// OpenUserStore opens the store of the given kind at path: "file" for a JSON file or "bolt" for an embedded bbolt database.
+// Missing parent directories are created.
+func OpenUserStore(kind, path string) (UserStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "file":
+		return NewFileUserStore(path)
+	case "bolt":
+		return NewBoltUserStore(path)
+	default:
+		return nil, fmt.Errorf("unknown user store %q (expected \"file\" or \"bolt\")", kind)
+	}
+}
+
+// FileUserStore keeps every user in memory and writes them to a JSON file on each change.
+// It suits small deployments; use BoltUserStore for more users.
+type FileUserStore struct {
+	path  string
+	mu    sync.Mutex
+	users map[string]models.User
+}
+
+// NewFileUserStore loads the users from the JSON file at path, which is created on the first write if missing.
+func NewFileUserStore(path string) (*FileUserStore, error) {
+	s := &FileUserStore{path: path, users: make(map[string]models.User)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.users); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// CreateUser implements UserStore.
+func (s *FileUserStore) CreateUser(user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.Username]; ok {
+		return ErrUserExists
+	}
+	s.users[user.Username] = user
+	return s.save()
+}
+
+// GetUser implements UserStore.
+func (s *FileUserStore) GetUser(username string) (models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateUser implements UserStore.
+func (s *FileUserStore) UpdateUser(user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.Username]; !ok {
+		return ErrUserNotFound
+	}
+	s.users[user.Username] = user
+	return s.save()
+}
+
+// ListUsers implements UserStore.
+func (s *FileUserStore) ListUsers() ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+// Close implements UserStore.
+func (s *FileUserStore) Close() error {
+	return nil
+}
+
+// save writes the users to a temporary file and renames it over the store, so a crash never leaves a partial file.
+// The caller must hold s.mu.
+func (s *FileUserStore) save() error {
+	data, err := json.MarshalIndent(s.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
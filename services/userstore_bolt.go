@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/json"
+	"groopie_local/models"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// usersBucket is the bbolt bucket holding the users as JSON, keyed by username.
+var usersBucket = []byte("users")
+
+// BoltUserStore stores users in an embedded bbolt database.
+type BoltUserStore struct {
+	db *bolt.DB
+}
+
+// NewBoltUserStore opens (or creates) the bbolt database at path.
+func NewBoltUserStore(path string) (*BoltUserStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(usersBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltUserStore{db: db}, nil
+}
+
+// CreateUser implements UserStore.
+func (s *BoltUserStore) CreateUser(user models.User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(user.Username)) != nil {
+			return ErrUserExists
+		}
+		return putUser(b, user)
+	})
+}
+
+// GetUser implements UserStore.
+func (s *BoltUserStore) GetUser(username string) (models.User, error) {
+	var user models.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(usersBucket).Get([]byte(username))
+		if data == nil {
+			return ErrUserNotFound
+		}
+		return json.Unmarshal(data, &user)
+	})
+	return user, err
+}
+
+// UpdateUser implements UserStore.
+func (s *BoltUserStore) UpdateUser(user models.User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(user.Username)) == nil {
+			return ErrUserNotFound
+		}
+		return putUser(b, user)
+	})
+}
+
+// ListUsers implements UserStore. Keys are sorted by bbolt, so users come back ordered by username.
+func (s *BoltUserStore) ListUsers() ([]models.User, error) {
+	var users []models.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(_, data []byte) error {
+			var user models.User
+			if err := json.Unmarshal(data, &user); err != nil {
+				return err
+			}
+			users = append(users, user)
+			return nil
+		})
+	})
+	return users, err
+}
+
+// Close implements UserStore.
+func (s *BoltUserStore) Close() error {
+	return s.db.Close()
+}
+
+// putUser encodes the user as JSON and stores it under its username.
+func putUser(b *bolt.Bucket, user models.User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(user.Username), data)
+}
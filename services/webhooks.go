@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// maxWebhookDeliveries bounds the number of delivery attempts kept in the log.
+	maxWebhookDeliveries = 200
+	// defaultWebhookRetries is the number of retries used when a webhook does not set MaxRetries.
+	defaultWebhookRetries = 3
+	// defaultWebhookBackoff is the delay before the first retry; it doubles after every attempt.
+	defaultWebhookBackoff = time.Second
+	// webhookQueueSize is the number of payloads waiting for a webhook beyond which new ones are dropped.
+	webhookQueueSize = 16
+)
+
+// Webhook is an outgoing notification target for dataset changes.
+type Webhook struct {
+	// URL receives a POST request with a JSON payload for each refresh with matching changes.
+	URL string `json:"url"`
+	// Events restricts the notified event types (e.g. "concert.added"). Empty means all events.
+	Events []string `json:"events"`
+	// ArtistIDs restricts the notifications to the given artists. Empty means all artists.
+	ArtistIDs []int `json:"artistIds"`
+	// Secret signs the payload with HMAC-SHA256 in the X-Groupie-Signature header when set.
+	Secret string `json:"secret"`
+	// MaxRetries is the number of retries after a failed delivery. Zero uses the default.
+	MaxRetries int `json:"maxRetries"`
+}
+
+// RedactedURL returns the scheme and host of the webhook URL, e.g. "https://hooks.example.com",
+// leaving out the path and query which often hold a token.
+func (wh *Webhook) RedactedURL() string {
+	return redactURL(wh.URL)
+}
+
+// redactURL returns the scheme and host of a URL, or "invalid URL" if it cannot be parsed.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid URL"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// matches reports whether the event passes the webhook's event and artist filters.
+func (wh *Webhook) matches(event ChangeEvent) bool {
+	if len(wh.Events) > 0 && !containsString(wh.Events, event.Type) {
+		return false
+	}
+	if len(wh.ArtistIDs) == 0 {
+		return true
+	}
+	for _, id := range wh.ArtistIDs {
+		if id == event.ArtistID {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body sent to a webhook.
+type WebhookPayload struct {
+	ID     string        `json:"id"`
+	Time   time.Time     `json:"time"`
+	Events []ChangeEvent `json:"events"`
+}
+
+// WebhookDelivery records a single delivery attempt.
+type WebhookDelivery struct {
+	DeliveryID string `json:"deliveryId"`
+	// URL is the redacted URL of the webhook: its scheme and host only.
+	URL        string        `json:"url"`
+	Attempt    int           `json:"attempt"`
+	Events     int           `json:"events"`
+	StatusCode int           `json:"statusCode"`
+	Error      string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Time       time.Time     `json:"time"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// WebhookDispatcher delivers change notifications to a set of webhooks and keeps a log of the attempts.
+// Each webhook has its own worker and queue, so a slow or unreachable endpoint only delays its own deliveries.
+type WebhookDispatcher struct {
+	hooks   []Webhook
+	client  *http.Client
+	backoff time.Duration
+	// queues holds the payloads waiting for each webhook, in the order of hooks.
+	queues []chan WebhookPayload
+
+	mu         sync.Mutex
+	deliveries []WebhookDelivery
+}
+
+// NewWebhookDispatcher creates a dispatcher for the given webhooks and starts their workers.
+// If httpClient is nil, the shared API client is used; a non-positive backoff uses the default.
+func NewWebhookDispatcher(hooks []Webhook, httpClient *http.Client, backoff time.Duration) *WebhookDispatcher {
+	if httpClient == nil {
+		httpClient = client
+	}
+	if backoff <= 0 {
+		backoff = defaultWebhookBackoff
+	}
+	d := &WebhookDispatcher{hooks: hooks, client: httpClient, backoff: backoff}
+	for _, wh := range hooks {
+		queue := make(chan WebhookPayload, webhookQueueSize)
+		d.queues = append(d.queues, queue)
+		go func(wh Webhook) {
+			for payload := range queue {
+				d.deliver(wh, payload)
+			}
+		}(wh)
+	}
+	return d
+}
+
+// Close stops the workers once the queued payloads are delivered. Dispatch must not be called afterwards.
+func (d *WebhookDispatcher) Close() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+}
+
+// LoadWebhooks reads a JSON array of webhooks from the given file.
+func LoadWebhooks(path string) ([]Webhook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Webhook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i, wh := range hooks {
+		u, err := url.Parse(wh.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("parse %s: webhook %d has an invalid url %q", path, i, wh.URL)
+		}
+	}
+	return hooks, nil
+}
+
+// Dispatch queues the events of the diff for every webhook with at least one matching event and returns
+// without waiting for the deliveries, so it never holds up a refresh. When a webhook already has
+// webhookQueueSize payloads waiting, the new one is dropped and recorded as such in the delivery log.
+func (d *WebhookDispatcher) Dispatch(diff Diff) {
+	events := diff.Events()
+	if len(events) == 0 {
+		return
+	}
+
+	for i, wh := range d.hooks {
+		var matching []ChangeEvent
+		for _, event := range events {
+			if wh.matches(event) {
+				matching = append(matching, event)
+			}
+		}
+		if len(matching) == 0 {
+			continue
+		}
+
+		payload := WebhookPayload{ID: newDeliveryID(), Time: diff.Time, Events: matching}
+		select {
+		case d.queues[i] <- payload:
+		default:
+			slog.Warn("Dropping webhook delivery, too many pending", "url", wh.RedactedURL(), "delivery", payload.ID)
+			d.logDelivery(WebhookDelivery{
+				DeliveryID: payload.ID,
+				URL:        wh.RedactedURL(),
+				Events:     len(payload.Events),
+				Error:      "dropped: too many deliveries pending",
+				Time:       time.Now(),
+			})
+		}
+	}
+}
+
+// deliver posts the payload to the webhook, retrying with exponential backoff on network errors,
+// 429 and 5xx responses.
+func (d *WebhookDispatcher) deliver(wh Webhook, payload WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Error encoding webhook payload", "url", wh.RedactedURL(), "error", err)
+		return
+	}
+
+	retries := wh.MaxRetries
+	if retries <= 0 {
+		retries = defaultWebhookRetries
+	}
+	backoff := d.backoff
+
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		delivery := d.post(wh, payload.ID, body)
+		delivery.Attempt = attempt
+		delivery.Events = len(payload.Events)
+		d.logDelivery(delivery)
+
+		if delivery.Success || !retryable(delivery.StatusCode) {
+			if !delivery.Success {
+				slog.Warn("Webhook rejected delivery", "url", wh.RedactedURL(), "delivery", payload.ID, "status", delivery.StatusCode)
+			}
+			return
+		}
+		if attempt <= retries {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	slog.Error("Giving up on webhook delivery", "url", wh.RedactedURL(), "delivery", payload.ID, "attempts", retries+1)
+}
+
+// post performs a single signed POST request to the webhook.
+func (d *WebhookDispatcher) post(wh Webhook, deliveryID string, body []byte) WebhookDelivery {
+	delivery := WebhookDelivery{DeliveryID: deliveryID, URL: wh.RedactedURL(), Time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", wh.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "groupie-tracker-webhooks")
+	req.Header.Set("X-Groupie-Delivery", deliveryID)
+	if wh.Secret != "" {
+		req.Header.Set("X-Groupie-Signature", "sha256="+SignPayload(wh.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	delivery.Duration = time.Since(delivery.Time)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	return delivery
+}
+
+// SignPayload returns the hex-encoded HMAC-SHA256 of body using secret.
+// Receivers compare it with the X-Groupie-Signature header (without the "sha256=" prefix).
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryable reports whether a delivery that ended with the given status code should be retried.
+// A zero status code means the request failed before a response was received.
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// logDelivery appends a delivery attempt to the bounded log.
+func (d *WebhookDispatcher) logDelivery(delivery WebhookDelivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deliveries = append(d.deliveries, delivery)
+	if len(d.deliveries) > maxWebhookDeliveries {
+		d.deliveries = d.deliveries[len(d.deliveries)-maxWebhookDeliveries:]
+	}
+}
+
+// Deliveries returns a copy of the delivery log, newest first.
+func (d *WebhookDispatcher) Deliveries() []WebhookDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deliveries := make([]WebhookDelivery, len(d.deliveries))
+	for i, delivery := range d.deliveries {
+		deliveries[len(d.deliveries)-1-i] = delivery
+	}
+	return deliveries
+}
+
+// Webhooks returns the configured webhooks.
+func (d *WebhookDispatcher) Webhooks() []Webhook {
+	return d.hooks
+}
+
+// newDeliveryID returns a random identifier for a delivery.
+func newDeliveryID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// webhooks is the dispatcher notified on cache refreshes, if configured.
+var webhooks *WebhookDispatcher
+
+// ConfigureWebhooks installs a dispatcher for the given webhooks and subscribes it to dataset changes.
+// It should be called once at startup.
+func ConfigureWebhooks(hooks []Webhook) {
+	webhooks = NewWebhookDispatcher(hooks, nil, 0)
+	OnChange(webhooks.Dispatch)
+}
+
+// ConfiguredWebhooks returns the installed dispatcher, or nil if webhooks are not configured.
+func ConfiguredWebhooks() *WebhookDispatcher {
+	return webhooks
+}
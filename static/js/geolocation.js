@@ -46,9 +46,6 @@ document.addEventListener("DOMContentLoaded", function () {
             pathCoordinates.push([lat, lon]);
             cardElement.classList.add('highlight');
   
-            // Update the line connecting the markers.
-            // polyline.setLatLngs(pathCoordinates);
-  
             // Display the zoom buttons.
             showButtons();
           } else {
@@ -68,6 +65,38 @@ document.addEventListener("DOMContentLoaded", function () {
       });
     });
   
+    // Draw the tour path in chronological order from the server-side export.
+    var artistId = document.getElementById('map').getAttribute('data-artist-id');
+    if (artistId) {
+      fetch(`/artist/${artistId}/tour.geojson`)
+        .then(response => response.json())
+        .then(data => {
+          data.features
+            .filter(feature => feature.geometry.type === 'LineString')
+            .forEach(feature => {
+              // GeoJSON stores [lon, lat]; Leaflet expects [lat, lon].
+              polyline.setLatLngs(feature.geometry.coordinates.map(c => [c[1], c[0]]));
+            });
+        })
+        .catch(err => console.error("Error fetching tour path:", err));
+
+      // Fill in the travel statistics, which need every location to be geocoded server-side.
+      if (document.getElementById('tour-distance')) {
+        fetch(`/artist/${artistId}/tour.json`)
+          .then(response => response.json())
+          .then(stats => {
+            var place = location => location.replace(/_/g, ' ').replace('-', ', ');
+            var pending = stats.unresolved.length ? ` (${stats.unresolved.length} location(s) not located yet)` : '';
+            document.getElementById('tour-distance').textContent = `${Math.round(stats.totalKm).toLocaleString()} km${pending}`;
+            document.getElementById('tour-legs').textContent = stats.legs.length;
+            document.getElementById('tour-longest').textContent = stats.longestLeg
+              ? `${place(stats.longestLeg.from)} to ${place(stats.longestLeg.to)} (${Math.round(stats.longestLeg.distanceKm).toLocaleString()} km)`
+              : 'none';
+          })
+          .catch(err => console.error("Error fetching tour statistics:", err));
+      }
+    }
+  
     // Zoom Out Button: Reset the map view.
     document.getElementById('zoom-out-button').addEventListener('click', function () {
       map.setView([0, 0], 2);
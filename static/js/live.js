@@ -0,0 +1,114 @@
+// Live updates pushed by the server through Server-Sent Events (/events).
+// The home page updates its artist cards in place; the artist page updates its details and concert dates.
+document.addEventListener("DOMContentLoaded", function () {
+  if (!window.EventSource) return;
+
+  var artistId = document.body.getAttribute("data-artist-id");
+  var source = new EventSource(artistId ? `/events?artist=${artistId}` : "/events");
+
+  // Show a banner at the top of the page, reusing it if it is already there.
+  function showBanner(message) {
+    var banner = document.getElementById("live-banner");
+    if (!banner) {
+      banner = document.createElement("div");
+      banner.id = "live-banner";
+      banner.className = "live-banner";
+      banner.addEventListener("click", function () {
+        window.location.reload();
+      });
+      document.body.prepend(banner);
+    }
+    banner.textContent = message + " Click to reload.";
+  }
+
+  source.addEventListener("artist", function (event) {
+    var data = JSON.parse(event.data);
+    if (artistId) {
+      updateArtistPage(data);
+    } else {
+      updateHomeCard(data);
+    }
+  });
+
+  source.addEventListener("refresh", function (event) {
+    var data = JSON.parse(event.data);
+    if (artistId || !data.changed) return;
+
+    // Artists that are not on the page yet can't be updated in place.
+    var missing = data.artistIds.filter(function (id) {
+      return !document.querySelector(`.link[data-artist-id="${id}"]`);
+    });
+    if (missing.length > 0) {
+      showBanner(`${missing.length} new or updated artist(s) available.`);
+    }
+  });
+
+  // Home page: refresh the card of a changed artist or mark it as removed.
+  function updateHomeCard(data) {
+    var link = document.querySelector(`.link[data-artist-id="${data.artistId}"]`);
+    if (!link) return;
+
+    if (!data.artist) {
+      link.classList.add("removed");
+      return;
+    }
+    var image = link.querySelector(".image");
+    image.src = data.artist.artist.image;
+    image.alt = data.artist.artist.name;
+    link.querySelector(".card-title").textContent = data.artist.artist.name;
+    link.classList.add("updated");
+  }
+
+  // Slug of a member name, as computed by models.MemberSlug.
+  function memberSlug(name) {
+    return name.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(Boolean).join("-");
+  }
+
+  // Replace the member links. Links to the members' other artists are restored on reload.
+  function updateMembers(container, members) {
+    container.textContent = "";
+    if (!members || members.length === 0) {
+      container.textContent = "No members listed.";
+      return;
+    }
+    members.forEach(function (name, index) {
+      if (index > 0) container.appendChild(document.createTextNode(", "));
+      var link = document.createElement("a");
+      link.href = `/member/${encodeURIComponent(memberSlug(name))}`;
+      link.className = "member-link";
+      link.textContent = name;
+      container.appendChild(link);
+    });
+  }
+
+  // Artist page: refresh the details and the concert dates of the displayed artist.
+  function updateArtistPage(data) {
+    if (!data.artist) {
+      showBanner("This artist has been removed.");
+      return;
+    }
+
+    var artist = data.artist.artist;
+    updateMembers(document.getElementById("artist-members"), artist.members);
+    document.getElementById("artist-first-album").textContent = artist.firstAlbum;
+    document.getElementById("artist-creation-date").textContent = artist.creationDate;
+
+    data.changes.forEach(function (change) {
+      var card = document.querySelector(`.location-card[data-location="${change.location}"]`);
+      if (change.type === "concert.added") {
+        if (!card) {
+          showBanner("New concert locations announced.");
+          return;
+        }
+        var date = document.createElement("p");
+        date.className = "date updated";
+        date.textContent = change.date;
+        card.querySelector(".concert-dates").appendChild(date);
+      } else if (change.type === "concert.removed" && card) {
+        card.querySelectorAll(".concert-dates .date").forEach(function (date) {
+          if (date.textContent.trim() === change.date) date.remove();
+        });
+      }
+    });
+  }
+});
@@ -32,7 +32,8 @@ async function fetchSuggestionsFromServer(query, searchType) {
   const response = await fetch(
     `${SUGGESTIONS_API}?q=${encodeURIComponent(
       query
-    )}&searchType=${encodeURIComponent(searchType)}`
+    )}&searchType=${encodeURIComponent(searchType)}`,
+    { headers: { Accept: "application/json" } }
   );
   if (!response.ok) {
     throw new Error("Network response was not ok");
@@ -45,7 +46,7 @@ function renderSuggestions(suggestions, suggestionsBox) {
   suggestionsBox.innerHTML = suggestions
     .map(
       (suggestion) => `
-        <div class="suggestion-item" data-input="${suggestion.input}">
+        <div class="suggestion-item" data-input="${suggestion.input}" data-url="${suggestion.url || ""}">
             ${suggestion.value}
         </div>
     `
@@ -73,6 +74,13 @@ function setupSuggestionSelection() {
 
   suggestionsBox.addEventListener("click", (event) => {
     if (event.target.classList.contains("suggestion-item")) {
+      // Suggestions with their own page (e.g. locations) open it directly.
+      const url = event.target.getAttribute("data-url");
+      if (url) {
+        window.location.href = url;
+        return;
+      }
+
       let input = event.target.getAttribute("data-input");
 
       // Remove any extra context from the suggestion (e.g., " - member of")